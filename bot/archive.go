@@ -0,0 +1,390 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// archiveFile is the name of the persisted message archive inside bot.data_dir
+const archiveFile = "archive.json"
+
+// archiveIndexInterval is how often new archived messages are embedded and the archive is saved
+const archiveIndexInterval = time.Minute
+
+// archiveEmbeddingBatch is how many messages are embedded per /embeddings request
+const archiveEmbeddingBatch = 64
+
+// maxRecalledLength caps how much of each recalled message is shown to the model
+const maxRecalledLength = 300
+
+// ArchivedMessage is a channel message kept for retrieval after it scrolled out of chat history
+type ArchivedMessage struct {
+	ID        string    `json:"id"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	ChannelID string    `json:"channel_id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	Embedding []float32 `json:"embedding,omitempty"`
+
+	tokens []string // Lexical search terms, derived from Content
+}
+
+// JumpLink returns the Discord link that opens the message
+func (m ArchivedMessage) JumpLink() string {
+	return messageLink(m.GuildID, m.ChannelID, m.ID)
+}
+
+// messageLink returns the Discord link that opens a message; guildID is empty in DMs
+func messageLink(guildID, channelID, messageID string) string {
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// MessageArchive keeps the most recent messages of each channel, with embeddings when an
+// embedding model is configured, and finds the ones most relevant to a question
+type MessageArchive struct {
+	mu            sync.Mutex
+	saveMu        sync.Mutex                   // Serializes Save so an older snapshot never overwrites a newer one
+	channels      map[string][]ArchivedMessage // channel ID -> messages, oldest first
+	maxPerChannel int
+	path          string
+	dirty         bool
+}
+
+// NewMessageArchive constructs a MessageArchive keeping up to maxPerChannel messages per channel,
+// persisted to path
+func NewMessageArchive(maxPerChannel int, path string) *MessageArchive {
+	return &MessageArchive{
+		channels:      make(map[string][]ArchivedMessage),
+		maxPerChannel: maxPerChannel,
+		path:          path,
+	}
+}
+
+// Load reads the persisted archive, if any
+func (a *MessageArchive) Load() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := readJSONFile(a.path, &a.channels); err != nil {
+		return err
+	}
+	if a.channels == nil {
+		a.channels = make(map[string][]ArchivedMessage)
+	}
+	for _, messages := range a.channels {
+		for i := range messages {
+			messages[i].tokens = tokenize(messages[i].Content)
+		}
+	}
+	return nil
+}
+
+// Save persists the archive if it changed since it was last saved. The archive is copied under
+// the lock and written outside it, so message handling isn't blocked while it is encoded.
+func (a *MessageArchive) Save() error {
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
+
+	a.mu.Lock()
+	if !a.dirty {
+		a.mu.Unlock()
+		return nil
+	}
+	// Cloning the slices is enough as embeddings are replaced, never modified in place
+	snapshot := make(map[string][]ArchivedMessage, len(a.channels))
+	for channelID, channel := range a.channels {
+		snapshot[channelID] = slices.Clone(channel)
+	}
+	a.dirty = false
+	a.mu.Unlock()
+
+	if err := writeJSONFile(a.path, snapshot); err != nil {
+		a.mu.Lock()
+		a.dirty = true
+		a.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Add stores messages, replacing earlier versions of edited ones, and drops each channel's
+// oldest messages beyond the limit
+func (a *MessageArchive) Add(messages ...ArchivedMessage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	touched := make(map[string]bool)
+	for _, message := range messages {
+		if strings.TrimSpace(message.Content) == "" {
+			continue
+		}
+		message.tokens = tokenize(message.Content)
+
+		channel := a.channels[message.ChannelID]
+		index := slices.IndexFunc(channel, func(m ArchivedMessage) bool { return m.ID == message.ID })
+		if index >= 0 {
+			if channel[index].Content == message.Content {
+				continue
+			}
+			channel[index] = message
+		} else {
+			channel = append(channel, message)
+			touched[message.ChannelID] = true
+		}
+		a.channels[message.ChannelID] = channel
+		a.dirty = true
+	}
+
+	for channelID := range touched {
+		channel := a.channels[channelID]
+		sort.SliceStable(channel, func(i, j int) bool { return snowflakeLess(channel[i].ID, channel[j].ID) })
+		if len(channel) > a.maxPerChannel {
+			channel = slices.Clone(channel[len(channel)-a.maxPerChannel:])
+		}
+		a.channels[channelID] = channel
+	}
+}
+
+// Remove deletes a message from the archive, e.g. after it was deleted on Discord
+func (a *MessageArchive) Remove(channelID, messageID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	channel := a.channels[channelID]
+	index := slices.IndexFunc(channel, func(m ArchivedMessage) bool { return m.ID == messageID })
+	if index < 0 {
+		return
+	}
+	a.channels[channelID] = slices.Delete(channel, index, index+1)
+	a.dirty = true
+}
+
+// LastMessageID returns the ID of the newest archived message in a channel, or "" if there is none
+func (a *MessageArchive) LastMessageID(channelID string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	channel := a.channels[channelID]
+	if len(channel) == 0 {
+		return ""
+	}
+	return channel[len(channel)-1].ID
+}
+
+// Unembedded returns up to limit archived messages that have no embedding yet
+func (a *MessageArchive) Unembedded(limit int) []ArchivedMessage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var out []ArchivedMessage
+	for _, channel := range a.channels {
+		for _, message := range channel {
+			if len(message.Embedding) == 0 {
+				out = append(out, message)
+				if len(out) == limit {
+					return out
+				}
+			}
+		}
+	}
+	return out
+}
+
+// SetEmbedding stores the embedding of an archived message, unless it was edited since
+func (a *MessageArchive) SetEmbedding(message ArchivedMessage, embedding []float32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	channel := a.channels[message.ChannelID]
+	index := slices.IndexFunc(channel, func(m ArchivedMessage) bool { return m.ID == message.ID })
+	if index < 0 || channel[index].Content != message.Content {
+		return
+	}
+	channel[index].Embedding = embedding
+	a.dirty = true
+}
+
+// Search returns up to k messages in a channel most relevant to query, best first, skipping
+// messages for which skip returns true. It ranks by embedding similarity when queryEmbedding is
+// given, keeping matches of at least bot.archive.min_similarity, and falls back to BM25 over
+// messages that have not been embedded yet.
+func (a *MessageArchive) Search(channelID, query string, queryEmbedding []float32, k int, skip func(messageID string) bool) []ArchivedMessage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var candidates []ArchivedMessage
+	for _, message := range a.channels[channelID] {
+		if !skip(message.ID) {
+			candidates = append(candidates, message)
+		}
+	}
+
+	tokens := make([][]string, len(candidates))
+	embeddings := make([][]float32, len(candidates))
+	for i, message := range candidates {
+		tokens[i] = message.tokens
+		embeddings[i] = message.Embedding
+	}
+
+	var out []ArchivedMessage
+	for _, i := range topScores(rankDocuments(query, queryEmbedding, tokens, embeddings, config.Bot.Archive.MinSimilarity), k, 0) {
+		out = append(out, candidates[i])
+	}
+	return out
+}
+
+// archivedMessageFrom converts a Discord message for the archive, naming the bot's own messages
+// after it so the model recognizes its earlier answers
+func archivedMessageFrom(discord *discordgo.Session, message *discordgo.Message) ArchivedMessage {
+	author := "unknown"
+	if message.Author != nil {
+		author = message.Author.Username
+	}
+	if isOwnMessage(discord, message) {
+		author = "you"
+	}
+	return ArchivedMessage{
+		ID:        message.ID,
+		GuildID:   message.GuildID,
+		ChannelID: message.ChannelID,
+		Author:    author,
+		Content:   message.Content,
+		Timestamp: message.Timestamp,
+	}
+}
+
+// archiveMessage adds a live message to the archive when bot.archive.enabled is set
+func archiveMessage(discord *discordgo.Session, message *discordgo.Message) {
+	if !config.Bot.Archive.Enabled || message.Author == nil {
+		return
+	}
+	messageArchive.Add(archivedMessageFrom(discord, message))
+}
+
+// backfillArchive archives the messages posted to a channel since its newest archived one,
+// up to bot.archive.backfill_messages
+func backfillArchive(ctx context.Context, discord *discordgo.Session, channel *discordgo.Channel) error {
+	messages, err := fetchMessagesSince(ctx, discord, channel.ID, messageArchive.LastMessageID(channel.ID), config.Bot.Archive.BackfillMessages)
+
+	archived := make([]ArchivedMessage, 0, len(messages))
+	for _, message := range messages {
+		if message.Author != nil {
+			// Messages fetched over REST do not carry the guild ID
+			message.GuildID = channel.GuildID
+			archived = append(archived, archivedMessageFrom(discord, message))
+		}
+	}
+	messageArchive.Add(archived...)
+	return err
+}
+
+// runArchiveIndexer embeds newly archived messages and saves the archive every
+// archiveIndexInterval until ctx is done
+func runArchiveIndexer(ctx context.Context) {
+	ticker := time.NewTicker(archiveIndexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if config.Grok.EmbeddingModel != "" {
+			embedArchivedMessages(ctx)
+		}
+		if err := messageArchive.Save(); err != nil {
+			log.Printf("Error saving message archive: %v", err)
+		}
+	}
+}
+
+// embedArchivedMessages embeds archived messages without an embedding, in batches, stopping at the first error
+func embedArchivedMessages(ctx context.Context) {
+	for ctx.Err() == nil {
+		pending := messageArchive.Unembedded(archiveEmbeddingBatch)
+		if len(pending) == 0 {
+			return
+		}
+
+		inputs := make([]string, len(pending))
+		for i, message := range pending {
+			inputs[i] = message.Author + ": " + message.Content
+		}
+		embeddings, err := grokClient.CreateEmbeddings(ctx, inputs)
+		if err != nil {
+			log.Printf("Error embedding archived messages, using lexical search for now: %v", err)
+			return
+		}
+		for i, message := range pending {
+			messageArchive.SetEmbedding(message, embeddings[i])
+		}
+	}
+}
+
+// recallFromArchive finds archived messages in channelID relevant to query that are not already
+// part of the conversation and formats them, with jump links, as context for the model.
+// It returns "" when the archive is disabled or nothing relevant was found.
+func recallFromArchive(ctx context.Context, channelID, query string, conversation []ChatMessage) string {
+	if !config.Bot.Archive.Enabled || strings.TrimSpace(query) == "" {
+		return ""
+	}
+
+	var queryEmbedding []float32
+	if config.Grok.EmbeddingModel != "" {
+		embeddings, err := grokClient.CreateEmbeddings(ctx, []string{query})
+		if err != nil {
+			log.Printf("Error embedding query, using lexical search: %v", err)
+		} else {
+			queryEmbedding = embeddings[0]
+		}
+	}
+
+	inConversation := make(map[string]bool, len(conversation))
+	for _, message := range conversation {
+		if message.MessageID != "" {
+			inConversation[message.MessageID] = true
+		}
+	}
+	matches := messageArchive.Search(channelID, query, queryEmbedding, config.Bot.Archive.Results, func(messageID string) bool {
+		return inConversation[messageID]
+	})
+	if len(matches) == 0 {
+		return ""
+	}
+
+	// Present the matches in the order they were posted
+	sort.Slice(matches, func(i, j int) bool { return snowflakeLess(matches[i].ID, matches[j].ID) })
+
+	var recalled strings.Builder
+	recalled.WriteString("Earlier messages from this channel that may be relevant. If you use them, link to them so people can jump to the original:\n")
+	for _, match := range matches {
+		content := match.Content
+		if len(content) > maxRecalledLength {
+			content = strings.ToValidUTF8(content[:maxRecalledLength], "") + "..."
+		}
+		date := match.Timestamp.In(botLocation).Format("2006-01-02")
+		fmt.Fprintf(&recalled, "- [%s] %s: %s (%s)\n", date, match.Author, content, match.JumpLink())
+	}
+	return strings.TrimSuffix(recalled.String(), "\n")
+}
+
+// handleMessageDelete removes deleted messages from the archive
+func handleMessageDelete(discord *discordgo.Session, event *discordgo.MessageDelete) {
+	if config.Bot.Archive.Enabled {
+		messageArchive.Remove(event.ChannelID, event.ID)
+	}
+}
@@ -0,0 +1,266 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ledongthuc/pdf"
+)
+
+// textContentTypes are attachment content types that are read as plain text
+var textContentTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/yaml",
+	"application/x-yaml",
+	"application/toml",
+	"application/javascript",
+	"application/x-javascript",
+	"application/typescript",
+	"application/x-sh",
+	"application/x-httpd-php",
+	"application/sql",
+}
+
+// textExtensions are file extensions read as plain text when the content type is missing or generic
+var textExtensions = []string{
+	".txt", ".md", ".markdown", ".rst", ".log", ".csv", ".tsv",
+	".json", ".jsonl", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf", ".env", ".properties",
+	".xml", ".html", ".htm", ".css", ".scss", ".svg",
+	".go", ".mod", ".sum", ".py", ".js", ".mjs", ".jsx", ".ts", ".tsx", ".rs", ".java", ".kt", ".kts",
+	".c", ".h", ".cc", ".cpp", ".hpp", ".cs", ".rb", ".php", ".swift", ".scala", ".lua", ".pl", ".r",
+	".sh", ".bash", ".zsh", ".ps1", ".bat", ".sql", ".graphql", ".proto", ".tf", ".dockerfile",
+	".diff", ".patch", ".gitignore",
+}
+
+// isTextAttachment checks if a Discord attachment looks like a text, code or data file
+func isTextAttachment(attachment *discordgo.MessageAttachment) bool {
+	if attachment == nil {
+		return false
+	}
+
+	// Check content type first, ignoring parameters such as charset
+	if attachment.ContentType != "" {
+		contentType := strings.ToLower(strings.TrimSpace(strings.Split(attachment.ContentType, ";")[0]))
+		if strings.HasPrefix(contentType, "text/") {
+			return true
+		}
+		for _, textType := range textContentTypes {
+			if contentType == textType {
+				return true
+			}
+		}
+	}
+
+	// Fallback: check file extension, which also covers code served as application/octet-stream
+	filename := strings.ToLower(attachment.Filename)
+	if filename == "dockerfile" || filename == "makefile" {
+		return true
+	}
+	ext := path.Ext(filename)
+	for _, textExt := range textExtensions {
+		if ext == textExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPDFAttachment checks if a Discord attachment is a PDF document
+func isPDFAttachment(attachment *discordgo.MessageAttachment) bool {
+	if attachment == nil {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(attachment.ContentType), "application/pdf") ||
+		strings.HasSuffix(strings.ToLower(attachment.Filename), ".pdf")
+}
+
+// attachedFilePrefix starts the text block formatFileText makes of each attachment
+const attachedFilePrefix = "[Attached file: "
+
+// extractFileTextsFromAttachments downloads text-like and PDF attachments and returns
+// their contents as labeled text blocks ready to be added to a ChatMessage
+func extractFileTextsFromAttachments(attachments []*discordgo.MessageAttachment) []string {
+	if !config.Bot.EnableFileAttachments {
+		return nil
+	}
+
+	var texts []string
+
+	for _, attachment := range attachments {
+		isText := isTextAttachment(attachment)
+		isPDF := !isText && isPDFAttachment(attachment)
+		if !isText && !isPDF {
+			continue
+		}
+
+		if attachment.Size > config.Bot.MaxFileAttachmentSize {
+			log.Printf("Skipping attachment %s: %d bytes exceeds max %d bytes", attachment.Filename, attachment.Size, config.Bot.MaxFileAttachmentSize)
+			continue
+		}
+
+		data, err := downloadAttachment(attachment.URL, int64(config.Bot.MaxFileAttachmentSize))
+		if err != nil {
+			log.Printf("Failed to download attachment %s: %v", attachment.Filename, err)
+			continue
+		}
+
+		var text string
+		if isPDF {
+			text, err = extractPDFText(data)
+		} else {
+			text, err = decodeUTF8Text(data)
+		}
+		if err != nil {
+			log.Printf("Failed to read attachment %s: %v", attachment.Filename, err)
+			continue
+		}
+
+		texts = append(texts, formatFileText(attachment.Filename, text, config.Bot.MaxAttachmentTextLength))
+		log.Printf("Successfully read attachment %s (%d bytes)", attachment.Filename, len(data))
+	}
+
+	return texts
+}
+
+// fileNotesFromAttachments returns a short placeholder for each attachment
+// extractFileTextsFromAttachments would read, without downloading anything
+func fileNotesFromAttachments(attachments []*discordgo.MessageAttachment) []string {
+	if !config.Bot.EnableFileAttachments {
+		return nil
+	}
+
+	var notes []string
+	for _, attachment := range attachments {
+		if !isTextAttachment(attachment) && !isPDFAttachment(attachment) {
+			continue
+		}
+		if attachment.Size > config.Bot.MaxFileAttachmentSize {
+			continue
+		}
+		notes = append(notes, fileNote(attachment.Filename))
+	}
+	return notes
+}
+
+// fileNote is the placeholder kept in chat history in place of a file's contents
+func fileNote(filename string) string {
+	return fmt.Sprintf("%s%s] (contents not kept in history)", attachedFilePrefix, filename)
+}
+
+// withoutFileTexts returns message with the contents of attached files replaced by
+// placeholders, so file text is only sent with the turn that attached it
+func withoutFileTexts(message ChatMessage) ChatMessage {
+	items, ok := message.Content.([]ContentItem)
+	if !ok {
+		return message
+	}
+
+	stripped := make([]ContentItem, len(items))
+	for i, item := range items {
+		if item.Type == "text" && strings.HasPrefix(item.Text, attachedFilePrefix) {
+			header, _, _ := strings.Cut(item.Text, "\n")
+			filename := strings.TrimSuffix(strings.TrimPrefix(header, attachedFilePrefix), "]")
+			item.Text = fileNote(filename)
+		}
+		stripped[i] = item
+	}
+	message.Content = stripped
+	return message
+}
+
+// downloadAttachment downloads a file from a URL, failing if it is larger than maxSize bytes
+func downloadAttachment(url string, maxSize int64) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("empty URL")
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("file download failed with status %d", resp.StatusCode)
+	}
+
+	// Read one byte past the limit so oversized files can be detected
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file data: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file too large (max %d bytes)", maxSize)
+	}
+
+	return data, nil
+}
+
+// decodeUTF8Text converts file bytes to a string, rejecting binary data
+func decodeUTF8Text(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 byte order mark
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("file appears to be binary")
+	}
+	if !utf8.Valid(data) {
+		return strings.ToValidUTF8(string(data), "�"), nil
+	}
+	return string(data), nil
+}
+
+// extractPDFText extracts the plain text of a PDF document
+func extractPDFText(data []byte) (text string, err error) {
+	// The PDF parser panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open PDF: %w", err)
+	}
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("failed to extract PDF text: %w", err)
+	}
+	extracted, err := io.ReadAll(plain)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF text: %w", err)
+	}
+	if strings.TrimSpace(string(extracted)) == "" {
+		return "", fmt.Errorf("PDF has no extractable text")
+	}
+	return string(extracted), nil
+}
+
+// formatFileText labels file contents with the file name, truncating to maxLength bytes
+func formatFileText(filename, text string, maxLength int) string {
+	truncated := false
+	if len(text) > maxLength {
+		text = strings.ToValidUTF8(text[:maxLength], "")
+		truncated = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s]\n```\n%s\n```", attachedFilePrefix, filename, strings.TrimRight(text, "\n"))
+	if truncated {
+		fmt.Fprintf(&b, "\n[File truncated to the first %d bytes]", maxLength)
+	}
+	return b.String()
+}
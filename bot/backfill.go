@@ -0,0 +1,293 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordPageSize is the maximum number of messages Discord returns per request
+const discordPageSize = 100
+
+// BackfillStatus is a snapshot of the startup history backfill progress
+type BackfillStatus struct {
+	State          string     `json:"state"` // "pending", "running" or "done"
+	ChannelsTotal  int        `json:"channels_total"`
+	ChannelsDone   int        `json:"channels_done"`
+	MessagesLoaded int        `json:"messages_loaded"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+var backfillMu sync.Mutex
+var backfillStatus = BackfillStatus{State: "pending"}
+
+// GetBackfillStatus returns the current history backfill progress
+func GetBackfillStatus() BackfillStatus {
+	backfillMu.Lock()
+	defer backfillMu.Unlock()
+	return backfillStatus
+}
+
+// updateBackfillStatus applies fn to the shared backfill status under its lock
+func updateBackfillStatus(fn func(status *BackfillStatus)) {
+	backfillMu.Lock()
+	defer backfillMu.Unlock()
+	fn(&backfillStatus)
+}
+
+// populateHistoryFromChannels reads recent messages from channels with read/write access to populate chat history.
+// Channels are processed in parallel, bounded by bot.backfill_concurrency, and only messages newer than the
+// last one already in a channel's history are fetched.
+func populateHistoryFromChannels(ctx context.Context, discord *discordgo.Session) {
+	log.Println("=== Populating chat history from recent messages ===")
+
+	now := time.Now()
+	updateBackfillStatus(func(status *BackfillStatus) {
+		status.State = "running"
+		status.StartedAt = &now
+	})
+
+	channels := backfillChannels(discord)
+	updateBackfillStatus(func(status *BackfillStatus) {
+		status.ChannelsTotal = len(channels)
+	})
+
+	jobs := make(chan *discordgo.Channel)
+	var wg sync.WaitGroup
+	for i := 0; i < config.Bot.BackfillConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for channel := range jobs {
+				loaded, err := backfillChannel(ctx, discord, channel)
+				if err != nil {
+					log.Printf("Error getting messages from channel %s: %v", channel.Name, err)
+				} else {
+					log.Printf("  - Processed %d messages from #%s", loaded, channel.Name)
+				}
+				if config.Bot.Archive.Enabled {
+					if err := backfillArchive(ctx, discord, channel); err != nil {
+						log.Printf("Error archiving messages from channel %s: %v", channel.Name, err)
+					}
+				}
+				updateBackfillStatus(func(status *BackfillStatus) {
+					status.ChannelsDone++
+					status.MessagesLoaded += loaded
+				})
+			}
+		}()
+	}
+
+	for _, channel := range channels {
+		select {
+		case jobs <- channel:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	finished := time.Now()
+	updateBackfillStatus(func(status *BackfillStatus) {
+		status.State = "done"
+		status.FinishedAt = &finished
+	})
+
+	if err := chatHistory.Save(dataPath(historyFile)); err != nil {
+		log.Printf("Error saving chat history: %v", err)
+	}
+
+	log.Println("=== Finished populating chat history ===")
+}
+
+// backfillChannels lists the text channels the bot can both read and write in
+func backfillChannels(discord *discordgo.Session) []*discordgo.Channel {
+	var out []*discordgo.Channel
+
+	for _, guild := range discord.State.Guilds {
+		log.Printf("Reading messages from server: %s", guild.Name)
+
+		channels, err := discord.GuildChannels(guild.ID)
+		if err != nil {
+			log.Printf("Error getting channels for guild %s: %v", guild.Name, err)
+			continue
+		}
+
+		for _, channel := range channels {
+			// Only process text channels
+			if channel.Type != discordgo.ChannelTypeGuildText {
+				continue
+			}
+
+			// Check if bot has permission to read and send messages
+			permissions, err := discord.UserChannelPermissions(discord.State.User.ID, channel.ID)
+			if err != nil {
+				log.Printf("Error checking permissions for channel %s: %v", channel.Name, err)
+				continue
+			}
+
+			canReadMessages := permissions&discordgo.PermissionViewChannel != 0
+			canSendMessages := permissions&discordgo.PermissionSendMessages != 0
+
+			// Only initialize history for channels with both read and write access
+			if !canReadMessages || !canSendMessages {
+				continue
+			}
+
+			out = append(out, channel)
+		}
+	}
+
+	return out
+}
+
+// backfillChannel fetches the messages posted to a channel since its newest known
+// message and merges them into the chat history. It returns the number of messages read.
+func backfillChannel(ctx context.Context, discord *discordgo.Session, channel *discordgo.Channel) (int, error) {
+	lastKnownID := chatHistory.LastMessageID(channel.ID)
+
+	messages, err := fetchMessagesSince(ctx, discord, channel.ID, lastKnownID, chatHistory.GetMax())
+	if err != nil {
+		return 0, err
+	}
+
+	chatHistory.Merge(channel.ID, historyFromMessages(discord, messages))
+	return len(messages), nil
+}
+
+// fetchMessagesSince pages backwards from the newest message in a channel until it reaches
+// afterID or has collected limit messages. Messages are returned newest first.
+func fetchMessagesSince(ctx context.Context, discord *discordgo.Session, channelID, afterID string, limit int) ([]*discordgo.Message, error) {
+	var out []*discordgo.Message
+	beforeID := ""
+
+	for len(out) < limit {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+
+		pageSize := min(discordPageSize, limit-len(out))
+		page, err := fetchMessagePage(ctx, discord, channelID, pageSize, beforeID)
+		if err != nil {
+			return out, err
+		}
+
+		reachedKnown := false
+		for _, msg := range page {
+			if msg == nil {
+				continue
+			}
+			if afterID != "" && !snowflakeLess(afterID, msg.ID) {
+				reachedKnown = true
+				break
+			}
+			out = append(out, msg)
+		}
+
+		if reachedKnown || len(page) < pageSize {
+			break
+		}
+		beforeID = page[len(page)-1].ID
+	}
+
+	return out, nil
+}
+
+// fetchMessagePage fetches one page of messages older than beforeID, waiting out rate limits
+func fetchMessagePage(ctx context.Context, discord *discordgo.Session, channelID string, limit int, beforeID string) ([]*discordgo.Message, error) {
+	for {
+		messages, err := discord.ChannelMessages(channelID, limit, beforeID, "", "", discordgo.WithContext(ctx))
+
+		var rateLimitErr *discordgo.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			log.Printf("Rate limited while reading channel %s, retrying in %s", channelID, rateLimitErr.RetryAfter)
+			select {
+			case <-time.After(rateLimitErr.RetryAfter):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		// discordgo rejects the whole page if any message has a component type it does not know
+		if errors.Is(err, discordgo.ErrJSONUnmarshal) {
+			log.Printf("Channel %s has messages discordgo cannot decode, falling back to raw REST: %v", channelID, err)
+			return fetchMessagePageRaw(ctx, discord, channelID, limit, beforeID)
+		}
+
+		return messages, err
+	}
+}
+
+// historyFromMessages converts fetched messages (newest first) into chat history entries (oldest first),
+// pairing messages that addressed the bot with the bot's reply
+func historyFromMessages(discord *discordgo.Session, messages []*discordgo.Message) []ChatMessage {
+	// Filter out messages without anything usable
+	var validMessages []*discordgo.Message
+	for _, msg := range messages {
+		if msg != nil && msg.Author != nil && (msg.Content != "" || len(msg.Attachments) > 0 || len(msg.Embeds) > 0 || len(msg.StickerItems) > 0) {
+			validMessages = append(validMessages, msg)
+		}
+	}
+	messages = validMessages
+
+	var history []ChatMessage
+
+	// Process messages in reverse order (oldest first)
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+
+		// Skip bot's own messages
+		if isOwnMessage(discord, msg) {
+			continue
+		}
+
+		// Determine if this was a message that addressed the bot, using the same rules as live handling
+		trigger := triggers.Match(discord, msg)
+		addressed := trigger.Addressed()
+
+		// Clean content for history
+		cleanContent := strings.TrimSpace(msg.Content)
+		if addressed {
+			cleanContent = trigger.Content
+		}
+
+		// Skip empty messages (no content and no usable attachments)
+		multimodalMsg := historyMessageFromDiscord(msg, cleanContent)
+		if isEmptyMessage(multimodalMsg) {
+			continue
+		}
+
+		// Add user message to history using multimodal message creation
+		history = append(history, multimodalMsg)
+
+		// If this was an addressed message, look for bot's response in the next few messages
+		if addressed {
+			for j := i - 1; j >= 0 && j > i-5; j-- {
+				responseMsg := messages[j]
+				if isOwnMessage(discord, responseMsg) {
+					responseContent := strings.TrimSpace(responseMsg.Content)
+					if responseContent != "" {
+						history = append(history, ChatMessage{
+							Role:      "assistant",
+							Content:   responseContent,
+							MessageID: responseMsg.ID,
+						})
+					}
+					break
+				}
+			}
+		}
+	}
+
+	return history
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +18,25 @@ import (
 
 var grokClient *GrokClient
 var chatHistory *ChatHistory
+var replyTracker *ReplyTracker
+var requestQueue *RequestQueue
+var imageCache *ImageCache
+var imageQuota *UserQuota
+var triggers *TriggerEngine
+var chimeIns *ChimeInTracker
+var emojiCatalog *EmojiCatalog
+var personaStore *PersonaStore
+var webhooks *WebhookManager
+var memoryStore *MemoryStore
+var messageArchive *MessageArchive
+var scheduler *Scheduler
+var reminders *ReminderStore
+
+// botLocation is the timezone from bot.timezone used for quiet hours and prompt dates
+var botLocation = time.UTC
+
+// rootCtx is cancelled when the bot shuts down; request work derives from it
+var rootCtx = context.Background()
 var config *Config
 
 // Discord message limits
@@ -25,9 +45,29 @@ const (
 	MaxDiscordFileSize      = 8 * 1024 * 1024 // 8MB file size limit
 )
 
-// RunWithConfig runs the bot with the provided configuration
+// historyFile is the name of the persisted chat history inside bot.data_dir
+const historyFile = "history.json"
+
+// GetUsage returns the Grok token usage accumulated since the bot started
+func GetUsage() UsageTotals {
+	if grokClient == nil {
+		return UsageTotals{}
+	}
+	return grokClient.Usage()
+}
+
+// RunWithConfig runs the bot with the provided configuration until interrupted
 func RunWithConfig(cfg *Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	RunWithConfigAsync(ctx, cfg)
+}
+
+// RunWithConfigAsync runs the bot with the provided configuration and supports context cancellation
+func RunWithConfigAsync(ctx context.Context, cfg *Config) {
 	config = cfg
+	rootCtx = ctx
 
 	// Initialize Grok client
 	grokClient = NewGrokClient(&config.Grok)
@@ -35,46 +75,71 @@ func RunWithConfig(cfg *Config) {
 	// Initialize chat history with configurable size
 	chatHistory = NewChatHistory(config.Bot.MaxHistory)
 
-	if config.Discord.Token == "" {
-		log.Fatal("Discord Bot token not provided")
-	}
+	// Initialize trigger -> reply tracking used to regenerate answers on edit
+	replyTracker = NewReplyTracker(config.Bot.EditWindow)
 
-	discord, err := discordgo.New("Bot " + config.Discord.Token)
-	if err != nil {
-		log.Fatal("Error connecting to discord")
-	}
+	// Initialize the per-channel request queue
+	requestQueue = NewRequestQueue(config.Bot.MaxConcurrentRequests, config.Bot.MaxQueueDepth)
 
-	discord.AddHandler(handleMessage)
+	// Initialize the image cache and expire old images periodically
+	imageCache = NewImageCache(dataPath("images"), config.Bot.ImageCacheMemorySize, config.Bot.ImageCacheDiskSize, config.Bot.ImageCacheTTL)
+	go pruneImageCache(ctx)
 
-	err = discord.Open()
+	// Initialize the rules deciding which messages address the bot
+	triggers = NewTriggerEngine(config.Bot.Triggers)
+
+	// Initialize unprompted reply tracking for chime-in channels
+	location, err := time.LoadLocation(config.Bot.Timezone)
 	if err != nil {
-		log.Fatal("Error opening Discord connection:", err)
+		log.Fatal("Error loading timezone:", err)
 	}
+	botLocation = location
+	chimeIns = NewChimeInTracker(config.Bot.ChimeIn, botLocation)
 
-	defer discord.Close()
+	// Initialize the per-guild emoji catalog; guild emojis arrive with GuildCreate events
+	emojiCatalog = NewEmojiCatalog(dataPath(emojiDescriptionsFile))
+	if err := emojiCatalog.Load(); err != nil {
+		log.Printf("Error loading emoji descriptions: %v", err)
+	}
 
-	// Populate chat history with recent messages if enabled
-	if config.Bot.EnableHistory {
-		populateHistoryFromChannels(discord)
+	// Initialize the per-channel persona choices
+	personaStore = NewPersonaStore(dataPath(personasFile))
+	if err := personaStore.Load(); err != nil {
+		log.Printf("Error loading persona choices: %v", err)
 	}
 
-	log.Println("Grok-bot running...")
+	// Initialize the per-channel webhooks used to post as personas
+	webhooks = NewWebhookManager()
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	<-c
+	// Initialize long-term user memories
+	memoryStore = NewMemoryStore(config.Bot.Memory.MaxFacts, dataPath(memoriesFile))
+	if err := memoryStore.Load(); err != nil {
+		log.Printf("Error loading user memories: %v", err)
+	}
 
-}
+	// Initialize the searchable archive of older channel messages
+	messageArchive = NewMessageArchive(config.Bot.Archive.MaxMessages, dataPath(archiveFile))
+	if config.Bot.Archive.Enabled {
+		if err := messageArchive.Load(); err != nil {
+			log.Printf("Error loading message archive: %v", err)
+		}
+		go runArchiveIndexer(ctx)
+	}
 
-// RunWithConfigAsync runs the bot with the provided configuration and supports context cancellation
-func RunWithConfigAsync(ctx context.Context, cfg *Config) {
-	config = cfg
+	// Initialize scheduled prompts; they start running once Discord is connected
+	scheduler = NewScheduler(dataPath(schedulesFile))
+	if err := scheduler.Load(); err != nil {
+		log.Printf("Error loading scheduled jobs: %v", err)
+	}
 
-	// Initialize Grok client
-	grokClient = NewGrokClient(&config.Grok)
+	// Initialize pending reminders; they are sent once Discord is connected
+	reminders = NewReminderStore(config.Bot.Reminders.MaxPerUser, dataPath(remindersFile))
+	if err := reminders.Load(); err != nil {
+		log.Printf("Error loading reminders: %v", err)
+	}
 
-	// Initialize chat history with configurable size
-	chatHistory = NewChatHistory(config.Bot.MaxHistory)
+	// Initialize per-user image generation quotas
+	imageQuota = NewUserQuota(config.Bot.ImageQuotaPerUser, config.Bot.ImageQuotaWindow)
 
 	if config.Discord.Token == "" {
 		log.Fatal("Discord Bot token not provided")
@@ -86,6 +151,11 @@ func RunWithConfigAsync(ctx context.Context, cfg *Config) {
 	}
 
 	discord.AddHandler(handleMessage)
+	discord.AddHandler(handleMessageUpdate)
+	discord.AddHandler(handleMessageDelete)
+	discord.AddHandler(handleInteraction)
+	discord.AddHandler(handleGuildCreate)
+	discord.AddHandler(handleGuildEmojisUpdate)
 
 	err = discord.Open()
 	if err != nil {
@@ -94,9 +164,17 @@ func RunWithConfigAsync(ctx context.Context, cfg *Config) {
 
 	defer discord.Close()
 
-	// Populate chat history with recent messages if enabled
+	registerSlashCommands(discord)
+
+	go scheduler.Run(ctx, discord)
+	go reminders.Run(ctx, discord)
+
+	// Populate chat history in the background, starting from what was persisted last run
 	if config.Bot.EnableHistory {
-		populateHistoryFromChannels(discord)
+		if err := chatHistory.Load(dataPath(historyFile)); err != nil {
+			log.Printf("Error loading saved chat history: %v", err)
+		}
+		go populateHistoryFromChannels(ctx, discord)
 	}
 
 	log.Println("Grok-bot running...")
@@ -104,219 +182,332 @@ func RunWithConfigAsync(ctx context.Context, cfg *Config) {
 	// Wait for context cancellation instead of signal
 	<-ctx.Done()
 	log.Println("Discord bot shutting down...")
-}
 
-// populateHistoryFromChannels reads recent messages from channels with read/write access to populate chat history
-func populateHistoryFromChannels(discord *discordgo.Session) {
-	log.Println("=== Populating chat history from recent messages ===")
+	if config.Bot.EnableHistory {
+		if err := chatHistory.Save(dataPath(historyFile)); err != nil {
+			log.Printf("Error saving chat history: %v", err)
+		}
+	}
+	if config.Bot.Archive.Enabled {
+		if err := messageArchive.Save(); err != nil {
+			log.Printf("Error saving message archive: %v", err)
+		}
+	}
+}
 
-	for _, guild := range discord.State.Guilds {
-		log.Printf("Reading messages from server: %s", guild.Name)
+func handleMessage(discord *discordgo.Session, message *discordgo.MessageCreate) {
+	archiveMessage(discord, message.Message)
+	if isOwnMessage(discord, message.Message) {
+		return
+	}
 
-		channels, err := discord.GuildChannels(guild.ID)
-		if err != nil {
-			log.Printf("Error getting channels for guild %s: %v", guild.Name, err)
-			continue
-		}
+	content := strings.TrimSpace(message.Content)
+	channelID := message.ChannelID
 
-		for _, channel := range channels {
-			// Only process text channels
-			if channel.Type != discordgo.ChannelTypeGuildText {
-				continue
-			}
+	trigger := triggers.Match(discord, message.Message)
+	if !trigger.Addressed() && triggers.ChimeIn() {
+		trigger = Trigger{Kind: TriggerChimeIn, Content: content}
+	}
 
-			// Check if bot has permission to read and send messages
-			permissions, err := discord.UserChannelPermissions(discord.State.User.ID, channel.ID)
+	if !trigger.Addressed() {
+		chatHistory.Append(channelID, historyMessageFromDiscord(message.Message, content))
+		if chimeIns.Observe(channelID) {
+			_, err := requestQueue.Submit(channelID, func() { considerChimingIn(discord, message.GuildID, channelID) })
 			if err != nil {
-				log.Printf("Error checking permissions for channel %s: %v", channel.Name, err)
-				continue
+				log.Printf("Skipping chime-in check in channel %s: %v", channelID, err)
 			}
+		}
+	} else {
+		// Use the content with bot mentions and command prefixes removed
+		content = trigger.Content
+		// Grok calls are serialized per channel so history turns stay in order
+		var job func()
+		if prompt, ok := parseDrawIntent(content); ok {
+			job = func() { respondWithImage(discord, message, prompt) }
+		} else if reminderIntentPattern.MatchString(content) {
+			userMessage := userMessageFromDiscord(message.Message, content)
+			job = func() { respondToReminder(discord, message, userMessage) }
+		} else {
+			userMessage := userMessageFromDiscord(message.Message, content)
+			job = func() { respondToMessage(discord, message, userMessage) }
+		}
 
-			canReadMessages := permissions&discordgo.PermissionViewChannel != 0
-			canSendMessages := permissions&discordgo.PermissionSendMessages != 0
+		position, err := requestQueue.Submit(channelID, job)
+		if err != nil {
+			log.Printf("Dropping request in channel %s: %v", channelID, err)
+			discord.ChannelMessageSend(channelID, "I'm swamped in this channel right now, please try again in a moment.")
+			return
+		}
+		if position > 0 {
+			discord.ChannelMessageSend(channelID, fmt.Sprintf("Queued, position %d. I'll get to you shortly.", position))
+		}
+	}
 
-			// Only initialize history for channels with both read and write access
-			if !canReadMessages || !canSendMessages {
-				continue
-			}
+}
 
-			// Get recent messages (last maxHistory messages per channel)
-			// Try different batch sizes to work around unknown component type errors
-			var messages []*discordgo.Message
-			var msgErr error
-
-			// Try to get messages, starting with the full amount
-			messages, msgErr = discord.ChannelMessages(channel.ID, chatHistory.GetMax(), "", "", "")
-			if msgErr != nil && strings.Contains(msgErr.Error(), "unknown component type") {
-				// If we get unknown component type error, try smaller batches
-				log.Printf("Channel %s has messages with unknown components, trying smaller batches...", channel.Name)
-
-				// Try smaller batches to work around problematic messages
-				for batchSize := chatHistory.GetMax() / 2; batchSize >= 5; batchSize /= 2 {
-					messages, msgErr = discord.ChannelMessages(channel.ID, batchSize, "", "", "")
-					if msgErr == nil {
-						log.Printf("Successfully retrieved %d messages from %s using batch size %d", len(messages), channel.Name, batchSize)
-						break
-					}
-					if !strings.Contains(msgErr.Error(), "unknown component type") {
-						break // Different error, don't retry
-					}
-				}
-			}
+// respondToMessage generates and sends a reply to a message that addressed the bot.
+// It runs on the channel's request queue.
+func respondToMessage(discord *discordgo.Session, message *discordgo.MessageCreate, userMessage ChatMessage) {
+	channelID := message.ChannelID
 
-			if msgErr != nil {
-				log.Printf("Error getting messages from channel %s: %v", channel.Name, msgErr)
-				continue
-			}
+	// Build messages with system prompt + prior channel history + new user message
+	messages := buildAnswerMessages(discord, message.GuildID, channelID, chatHistory.Get(channelID), userMessage)
 
-			// Filter out messages that couldn't be parsed due to unknown components
-			var validMessages []*discordgo.Message
-			for _, msg := range messages {
-				if msg != nil && (msg.Content != "" || len(msg.Attachments) > 0) {
-					validMessages = append(validMessages, msg)
-				}
-			}
-			messages = validMessages
-
-			// Process messages in reverse order (oldest first)
-			for i := len(messages) - 1; i >= 0; i-- {
-				msg := messages[i]
-
-				// Skip bot's own messages
-				if msg.Author.ID == discord.State.User.ID {
-					continue
-				}
-
-				// Skip empty messages (no content and no attachments)
-				content := strings.TrimSpace(msg.Content)
-				imageURLs := extractImageURLsFromAttachments(msg.Attachments)
-				if content == "" && len(imageURLs) == 0 {
-					continue
-				}
-
-				// Determine if this was a message that addressed the bot
-				addressed := false
-				for _, mention := range msg.Mentions {
-					if mention.ID == discord.State.User.ID {
-						addressed = true
-						break
-					}
-				}
-				if !addressed && content != "" {
-					addressed = strings.Contains(strings.ToLower(content), "@grok")
-				}
-
-				// Clean content for history
-				cleanContent := content
-				if addressed {
-					cleanContent = strings.ReplaceAll(cleanContent, fmt.Sprintf("<@%s>", discord.State.User.ID), "")
-					cleanContent = strings.ReplaceAll(strings.ToLower(cleanContent), "@grok", "")
-					cleanContent = strings.TrimSpace(cleanContent)
-				}
-
-				if cleanContent != "" || len(imageURLs) > 0 {
-					// Add user message to history using multimodal message creation
-					multimodalMsg := CreateMultimodalMessage("user", cleanContent, imageURLs, msg.Author.Username)
-					chatHistory.Append(channel.ID, multimodalMsg)
-
-					// If this was an addressed message, look for bot's response in subsequent messages
-					if addressed {
-						// Look for bot's response in the next few messages
-						for j := i - 1; j >= 0 && j > i-5; j-- {
-							responseMsg := messages[j]
-							if responseMsg.Author.ID == discord.State.User.ID {
-								responseContent := strings.TrimSpace(responseMsg.Content)
-								if responseContent != "" {
-									chatHistory.Append(channel.ID, ChatMessage{
-										Role:    "assistant",
-										Content: responseContent,
-									})
-								}
-								break
-							}
-						}
-					}
-				}
-			}
+	// Keep the typing indicator alive until the response is ready
+	stopTyping := keepTyping(rootCtx, discord, channelID)
+
+	// Get response from Grok
+	completion, err := grokClient.CreateChatCompletionDetailed(rootCtx, messages, withPersona(channelID, CompletionOptions{
+		ReasoningEffort: reasoningEffortFor(message.GuildID, chatCommandName),
+		Search:          searchParametersFor(channelID),
+	}))
+	stopTyping()
+	if err != nil {
+		log.Printf("Error getting Grok response: %v", err)
+		discord.ChannelMessageSend(channelID, "Sorry, I encountered an error processing your request. Please try again.")
+		return
+	}
+	completion.Content = emojiCatalog.Repair(message.GuildID, completion.Content)
+
+	// Send the response back to Discord
+	content := completion.Content + formatCitations(completion.Citations)
+	reply, err := sendReply(discord, channelID, content, completion.ReasoningContent)
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
 
-			log.Printf("  - Processed %d messages from #%s", len(messages), channel.Name)
+	// Append to history: user then assistant
+	assistantMessage := CreateTextMessage("assistant", completion.Content, "")
+	if reply != nil {
+		assistantMessage.MessageID = reply.ID
+		if config.Bot.RegenerateOnEdit {
+			replyTracker.Track(message.ID, channelID, reply.ID, message.Content)
 		}
 	}
+	chatHistory.Append(channelID, withoutFileTexts(userMessage))
+	chatHistory.Append(channelID, assistantMessage)
+	chimeIns.Reset(channelID)
 
-	log.Println("=== Finished populating chat history ===")
+	// Extract memories off the channel queue so the next request isn't held up
+	go rememberFromTurn(message.Author.ID, userMessage, completion.Content)
 }
 
-func handleMessage(discord *discordgo.Session, message *discordgo.MessageCreate) {
-	if message.Author.ID == discord.State.User.ID {
+// handleMessageUpdate adds late embed images to history and regenerates the bot's reply when a
+// message that addressed it is edited within the configured edit window, editing the reply in place
+func handleMessageUpdate(discord *discordgo.Session, update *discordgo.MessageUpdate) {
+	if update.Message == nil || update.Author == nil {
+		return
+	}
+	archiveMessage(discord, update.Message)
+	if isOwnMessage(discord, update.Message) {
 		return
 	}
 
-	content := strings.TrimSpace(message.Content)
-	channelID := message.ChannelID
-	attachments := message.Attachments
-	imageURLs := extractImageURLsFromAttachments(attachments)
+	// Link previews are usually attached after the message was created
+	if len(update.Embeds) > 0 && chatHistory.Contains(update.ChannelID, update.ID) {
+		if imageURLs := extractImageURLsFromEmbeds(update.Embeds); len(imageURLs) > 0 {
+			chatHistory.Update(update.ChannelID, update.ID, func(msg ChatMessage) ChatMessage {
+				return AddImagesToMessage(msg, imageURLs)
+			})
+		}
+	}
 
-	if !doesMessageMention(message.Mentions, discord.State.User.ID) {
+	if !config.Bot.RegenerateOnEdit {
+		return
+	}
 
-		chatHistory.Append(channelID, CreateMultimodalMessage("user", content, imageURLs, message.Author.Username))
-	} else {
-		// Remove the bot mention from the content
-		content = strings.ReplaceAll(content, fmt.Sprintf("<@%s>", discord.State.User.ID), "")
+	record, ok := replyTracker.Lookup(update.ID)
+	if !ok {
+		return
+	}
 
-		// Build messages with system prompt + prior channel history + new user message
-		prior := chatHistory.Get(channelID)
-		messages := make([]ChatMessage, 0, 1+len(prior)+1)
-		messages = append(messages, ChatMessage{Role: "system", Content: config.Bot.DefaultSystemMessage})
-		messages = append(messages, prior...)
-		messages = append(messages, CreateMultimodalMessage("user", content, imageURLs, message.Author.Username))
+	// Embed unfurls and other non-content updates also arrive as edits
+	if update.Content == record.Content {
+		return
+	}
+	trigger := triggers.Match(discord, update.Message)
+	if !trigger.Addressed() {
+		return
+	}
 
-		// Send typing indicator
-		discord.ChannelTyping(message.ChannelID)
+	userMessage := userMessageFromDiscord(update.Message, trigger.Content)
 
-		// Get response from Grok
-		response, err := grokClient.CreateChatCompletion(messages)
-		if err != nil {
-			log.Printf("Error getting Grok response: %v", err)
-			discord.ChannelMessageSend(message.ChannelID, "Sorry, I encountered an error processing your request. Please try again.")
-			return
-		}
+	_, err := requestQueue.Submit(update.ChannelID, func() {
+		regenerateReply(discord, update, record, userMessage)
+	})
+	if err != nil {
+		log.Printf("Dropping regeneration for edited message %s: %v", update.ID, err)
+	}
+}
 
-		// Append to history: user then assistant
-		chatHistory.Append(channelID, CreateMultimodalMessage("user", content, imageURLs, message.Author.Username))
-		chatHistory.Append(channelID, CreateTextMessage("assistant", response, ""))
+// regenerateReply re-answers an edited message and edits the previous reply in place.
+// It runs on the channel's request queue.
+func regenerateReply(discord *discordgo.Session, update *discordgo.MessageUpdate, record replyRecord, userMessage ChatMessage) {
+	// Regenerate from the history as it stood before the original message
+	messages := buildAnswerMessages(discord, update.GuildID, update.ChannelID, chatHistory.GetBefore(update.ChannelID, update.ID), userMessage)
+
+	stopTyping := keepTyping(rootCtx, discord, update.ChannelID)
+	completion, err := grokClient.CreateChatCompletionDetailed(rootCtx, messages, withPersona(update.ChannelID, CompletionOptions{
+		ReasoningEffort: reasoningEffortFor(update.GuildID, chatCommandName),
+		Search:          searchParametersFor(update.ChannelID),
+	}))
+	stopTyping()
+	if err != nil {
+		log.Printf("Error regenerating Grok response for edited message %s: %v", update.ID, err)
+		return
+	}
+	completion.Content = emojiCatalog.Repair(update.GuildID, completion.Content)
 
-		// Send the response back to Discord
-		err = sendMessage(discord, message.ChannelID, response)
-		if err != nil {
-			log.Printf("Error sending message: %v", err)
+	content := completion.Content + formatCitations(completion.Citations)
+	if err := editMessage(discord, record.ChannelID, record.ReplyID, content, completion.ReasoningContent); err != nil {
+		log.Printf("Error editing reply %s: %v", record.ReplyID, err)
+		return
+	}
+	replyTracker.Track(update.ID, record.ChannelID, record.ReplyID, update.Content)
+
+	assistantMessage := CreateTextMessage("assistant", completion.Content, "")
+	assistantMessage.MessageID = record.ReplyID
+	chatHistory.Replace(update.ChannelID, update.ID, withoutFileTexts(userMessage))
+	chatHistory.Replace(update.ChannelID, record.ReplyID, assistantMessage)
+}
+
+// userMessageFromDiscord builds a user ChatMessage from a Discord message, using content as its
+// text and adding the message's image and file attachments as multimodal input
+func userMessageFromDiscord(message *discordgo.Message, content string) ChatMessage {
+	return chatMessageFromDiscord(message, content, extractFileTextsFromAttachments(message.Attachments))
+}
+
+// historyMessageFromDiscord is userMessageFromDiscord for messages that are only kept as
+// history: attached files are noted by name and never downloaded
+func historyMessageFromDiscord(message *discordgo.Message, content string) ChatMessage {
+	return chatMessageFromDiscord(message, content, fileNotesFromAttachments(message.Attachments))
+}
+
+// chatMessageFromDiscord builds a user ChatMessage with the given file texts
+func chatMessageFromDiscord(message *discordgo.Message, content string, fileTexts []string) ChatMessage {
+	imageURLs := extractImageURLsFromAttachments(message.Attachments)
+	imageURLs = append(imageURLs, extractImageURLsFromEmbeds(message.Embeds)...)
+	imageURLs = append(imageURLs, extractImageURLsFromStickers(message.StickerItems)...)
+	for _, attachment := range message.Attachments {
+		// HEIC images are skipped; tell the model so it can ask for another format
+		if isHEICAttachment(attachment) {
+			fileTexts = append(fileTexts, fmt.Sprintf("[Attached image %s could not be read: HEIC images are not supported, ask for a JPEG or PNG]", attachment.Filename))
 		}
 	}
 
+	userMessage := CreateMessageWithFiles("user", content, fileTexts, imageURLs, message.Author.Username)
+	userMessage.MessageID = message.ID
+	userMessage.UserID = message.Author.ID
+	return userMessage
+}
+
+// isEmptyMessage reports whether a ChatMessage has neither text nor multimodal content
+func isEmptyMessage(message ChatMessage) bool {
+	switch content := message.Content.(type) {
+	case string:
+		return content == ""
+	case []ContentItem:
+		return len(content) == 0
+	default:
+		return content == nil
+	}
+}
+
+// buildAnswerMessages assembles the request answering userMessage: the prior history plus
+// relevant archived messages and knowledge base excerpts, with the system prompt
+func buildAnswerMessages(discord *discordgo.Session, guildID, channelID string, prior []ChatMessage, userMessage ChatMessage) []ChatMessage {
+	query := messageText(userMessage)
+	// The message being answered is archived already and would otherwise be its own best match
+	conversation := append(slices.Clip(prior), userMessage)
+	if recalled := recallFromArchive(rootCtx, channelID, query, conversation); recalled != "" {
+		prior = append(prior, ChatMessage{Role: "system", Content: recalled})
+	}
+	if excerpts := consultKnowledgeBase(rootCtx, guildID, query); excerpts != "" {
+		prior = append(prior, ChatMessage{Role: "system", Content: excerpts})
+	}
+	return buildChatMessages(discord, channelID, prior, userMessage)
+}
+
+// buildChatMessages assembles the channel's system prompt, prior channel history and the new user message,
+// encoding cached images for the request
+func buildChatMessages(discord *discordgo.Session, channelID string, prior []ChatMessage, userMessage ChatMessage) []ChatMessage {
+	messages := make([]ChatMessage, 0, 1+len(prior)+1)
+	messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt(discord, channelID, userMessage.UserID, userMessage.Username)})
+	messages = append(messages, prior...)
+	messages = append(messages, userMessage)
+	return resolveImages(messages)
+}
+
+// pruneImageCache expires cached images at startup and then hourly until ctx is done
+func pruneImageCache(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		imageCache.Prune()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 // sendMessage sends a message to Discord, handling size limits by sending as file if needed
-func sendMessage(discord *discordgo.Session, channelID, content string) error {
+func sendMessage(discord *discordgo.Session, channelID, content string) (*discordgo.Message, error) {
 	// Check if message is within Discord's character limit
 	maxLength := config.Bot.MaxMessageSize
 	if len(content) <= maxLength {
-		_, err := discord.ChannelMessageSend(channelID, content)
-		return err
+		return postMessage(discord, channelID, &discordgo.MessageSend{Content: content})
 	}
 
 	// Message is too long, send as markdown file
 	return sendAsMarkdownFile(discord, channelID, content)
 }
 
+// editMessage replaces the content of a previously sent message, switching between
+// inline text and a markdown file attachment as the size requires
+func editMessage(discord *discordgo.Session, channelID, messageID, content, reasoning string) error {
+	edit := discordgo.NewMessageEdit(channelID, messageID)
+
+	// Drop any file attached by a previous version of the reply
+	attachments := []*discordgo.MessageAttachment{}
+	edit.Attachments = &attachments
+
+	content, files := replyParts(content, reasoning)
+	if len(content) <= config.Bot.MaxMessageSize {
+		edit.SetContent(content)
+	} else {
+		if len(content) > MaxDiscordFileSize {
+			return fmt.Errorf("response too large even for file upload (%d bytes)", len(content))
+		}
+		edit.SetContent("")
+		edit.Files = []*discordgo.File{{
+			Name:        markdownFilename(),
+			ContentType: "text/markdown",
+			Reader:      strings.NewReader(content),
+		}}
+	}
+	edit.Files = append(edit.Files, files...)
+
+	return editPostedMessage(discord, edit)
+}
+
+// markdownFilename returns a timestamped filename for responses sent as files
+func markdownFilename() string {
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	return fmt.Sprintf("grok_response_%s.md", timestamp)
+}
+
 // sendAsMarkdownFile sends content as a markdown file attachment
-func sendAsMarkdownFile(discord *discordgo.Session, channelID, content string) error {
+func sendAsMarkdownFile(discord *discordgo.Session, channelID, content string) (*discordgo.Message, error) {
 	// Create a temporary file
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("grok_response_%s.md", timestamp)
+	filename := markdownFilename()
 
 	// Create the file
 	file, err := os.CreateTemp("", filename)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
 	}
 	defer os.Remove(file.Name()) // Clean up temp file
 
@@ -324,33 +515,35 @@ func sendAsMarkdownFile(discord *discordgo.Session, channelID, content string) e
 	_, err = file.WriteString(content)
 	if err != nil {
 		file.Close()
-		return fmt.Errorf("failed to write to temp file: %w", err)
+		return nil, fmt.Errorf("failed to write to temp file: %w", err)
 	}
 	file.Close()
 
 	// Check file size
 	fileInfo, err := os.Stat(file.Name())
 	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
 	if fileInfo.Size() > MaxDiscordFileSize {
-		return fmt.Errorf("response too large even for file upload (%d bytes)", fileInfo.Size())
+		return nil, fmt.Errorf("response too large even for file upload (%d bytes)", fileInfo.Size())
 	}
 
 	// Send file to Discord
 	fileReader, err := os.Open(file.Name())
 	if err != nil {
-		return fmt.Errorf("failed to open file for reading: %w", err)
+		return nil, fmt.Errorf("failed to open file for reading: %w", err)
 	}
 	defer fileReader.Close()
 
-	_, err = discord.ChannelFileSend(channelID, filename, fileReader)
+	msg, err := postMessage(discord, channelID, &discordgo.MessageSend{
+		Files: []*discordgo.File{{Name: filename, ContentType: "text/markdown", Reader: fileReader}},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to send file: %w", err)
+		return nil, fmt.Errorf("failed to send file: %w", err)
 	}
 
-	return nil
+	return msg, nil
 }
 
 // downloadImage downloads an image from a URL and returns the bytes and content type
@@ -436,30 +629,79 @@ func validateImageURL(url string) bool {
 }
 
 // extractImageURLsFromAttachments extracts image URLs from Discord message attachments,
-// downloads them, and converts them to base64 data URLs for reliable API access
+// downloads them, and stores them in the image cache. The returned URLs are cache references
+// that are encoded as base64 data URLs only when a request is built.
 func extractImageURLsFromAttachments(attachments []*discordgo.MessageAttachment) []string {
 	var imageURLs []string
 
 	for _, attachment := range attachments {
+		if isHEICAttachment(attachment) {
+			log.Printf("Skipping HEIC image %s: no pure-Go HEIC decoder is available", attachment.Filename)
+			continue
+		}
 		if attachment == nil || !isImageAttachment(attachment) {
 			continue
 		}
 
-		// Download the image
-		imageData, contentType, err := downloadImage(attachment.URL)
+		ref, err := cacheImage(attachment.URL, attachment.Filename)
 		if err != nil {
-			log.Printf("Failed to download image from %s: %v", attachment.URL, err)
+			log.Printf("Failed to process image %s: %v", attachment.Filename, err)
 			continue
 		}
+		imageURLs = append(imageURLs, ref)
+	}
 
-		// Convert to base64 data URL
-		dataURL := imageToDataURL(imageData, contentType)
-		imageURLs = append(imageURLs, dataURL)
+	return imageURLs
+}
 
-		log.Printf("Successfully converted image %s to base64 data URL (%d bytes)", attachment.Filename, len(imageData))
+// cacheImage downloads an image, normalizes it and stores it in the image cache,
+// returning the cache reference. name is only used for logging.
+func cacheImage(url, name string) (string, error) {
+	// Download the image
+	imageData, _, err := downloadImage(url)
+	if err != nil {
+		return "", err
 	}
 
-	return imageURLs
+	// Reject data that is not a supported image type, whatever the server claimed
+	if sniffed := http.DetectContentType(imageData); !isSupportedImageType(sniffed) {
+		return "", fmt.Errorf("unsupported image content type %q", sniffed)
+	}
+
+	// Downscale and re-encode to a format and size the API accepts
+	normalized, contentType, err := normalizeImage(imageData)
+	if err != nil {
+		return "", err
+	}
+
+	// Store in the cache and keep only a reference in history
+	ref := imageCache.Put(normalized, contentType)
+
+	log.Printf("Successfully cached image %s (%d bytes, %d after processing)", name, len(imageData), len(normalized))
+	return ref, nil
+}
+
+// Grok API supported image formats: JPEG, PNG, WebP
+// GIFs are accepted too and converted to a still of their first frame
+var supportedImageTypes = []string{
+	"image/jpeg",
+	"image/jpg",
+	"image/png",
+	"image/webp",
+	"image/gif",
+}
+
+var supportedImageExtensions = []string{".jpg", ".jpeg", ".png", ".webp", ".gif"}
+
+// isSupportedImageType checks if a content type is one of the supported image formats
+func isSupportedImageType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, supportedType := range supportedImageTypes {
+		if contentType == supportedType {
+			return true
+		}
+	}
+	return false
 }
 
 // isImageAttachment checks if a Discord attachment is an image supported by Grok API
@@ -468,29 +710,15 @@ func isImageAttachment(attachment *discordgo.MessageAttachment) bool {
 		return false
 	}
 
-	// Grok API supported image formats: JPEG, PNG, WebP
-	supportedImageTypes := []string{
-		"image/jpeg",
-		"image/jpg",
-		"image/png",
-		"image/webp",
-	}
-
 	// Check content type first (most reliable)
-	if attachment.ContentType != "" {
-		contentType := strings.ToLower(attachment.ContentType)
-		for _, supportedType := range supportedImageTypes {
-			if contentType == supportedType {
-				return true
-			}
-		}
+	if attachment.ContentType != "" && isSupportedImageType(attachment.ContentType) {
+		return true
 	}
 
 	// Fallback: check file extension if content type is not available
 	if attachment.Filename != "" {
 		filename := strings.ToLower(attachment.Filename)
-		supportedExtensions := []string{".jpg", ".jpeg", ".png", ".webp"}
-		for _, ext := range supportedExtensions {
+		for _, ext := range supportedImageExtensions {
 			if strings.HasSuffix(filename, ext) {
 				return true
 			}
@@ -500,6 +728,17 @@ func isImageAttachment(attachment *discordgo.MessageAttachment) bool {
 	return false
 }
 
+// isHEICAttachment checks if a Discord attachment is a HEIC/HEIF image
+func isHEICAttachment(attachment *discordgo.MessageAttachment) bool {
+	if attachment == nil {
+		return false
+	}
+	contentType := strings.ToLower(attachment.ContentType)
+	filename := strings.ToLower(attachment.Filename)
+	return contentType == "image/heic" || contentType == "image/heif" ||
+		strings.HasSuffix(filename, ".heic") || strings.HasSuffix(filename, ".heif")
+}
+
 func doesMessageMention(users []*discordgo.User, id string) bool {
 	for _, user := range users {
 		if user.ID == id {
@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// chimeInCommandName is the command name used for the reasoning override of unprompted replies
+const chimeInCommandName = "chime_in"
+
+// chimeInClassifierPrompt instructs the relevance classifier deciding whether to join a conversation
+const chimeInClassifierPrompt = `You decide whether Grok, a casual and sometimes snarky Discord bot, should join a conversation it was not asked to join.
+
+You will be given the latest messages in a channel. Only say yes when Grok has something genuinely useful, interesting or funny to add: a correction, a missing fact, an answer to an open question nobody has answered, or a joke that fits. Say no for private or sensitive conversations, conversations that are winding down, and anything where an uninvited bot would be annoying.`
+
+// chimeInDecision is the classifier's structured answer
+type chimeInDecision struct {
+	Respond bool    `json:"respond" description:"Whether Grok should post a message now"`
+	Score   float64 `json:"score" description:"How valuable a contribution would be, from 0 to 1"`
+	Reason  string  `json:"reason" description:"One sentence on what Grok would add"`
+}
+
+// ChimeInTracker counts unaddressed messages per channel and enforces cooldowns, quiet
+// hours and a per-channel rate limit on unprompted replies
+type ChimeInTracker struct {
+	mu         sync.Mutex
+	config     ChimeInConfig
+	location   *time.Location
+	unanswered map[string]int
+	lastChimed map[string]time.Time
+	rate       *UserQuota
+}
+
+// NewChimeInTracker constructs a ChimeInTracker; quiet hours are interpreted in location
+func NewChimeInTracker(cfg ChimeInConfig, location *time.Location) *ChimeInTracker {
+	return &ChimeInTracker{
+		config:     cfg,
+		location:   location,
+		unanswered: make(map[string]int),
+		lastChimed: make(map[string]time.Time),
+		rate:       NewUserQuota(cfg.MaxMessages, cfg.Window),
+	}
+}
+
+// Observe counts an unaddressed message in channelID and reports whether enough have
+// accumulated since the bot last spoke to consider chiming in
+func (t *ChimeInTracker) Observe(channelID string) bool {
+	if !slices.Contains(t.config.Channels, channelID) {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.unanswered[channelID]++
+	if t.unanswered[channelID] < t.config.AfterMessages {
+		return false
+	}
+	t.unanswered[channelID] = 0
+	return true
+}
+
+// Reset restarts the unaddressed message count after the bot speaks in channelID
+func (t *ChimeInTracker) Reset(channelID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.unanswered, channelID)
+}
+
+// Ready reports whether channelID is outside its cooldown and quiet hours
+func (t *ChimeInTracker) Ready(channelID string, now time.Time) bool {
+	t.mu.Lock()
+	last, ok := t.lastChimed[channelID]
+	t.mu.Unlock()
+	if ok && now.Sub(last) < t.config.Cooldown {
+		return false
+	}
+	return !t.inQuietHours(now)
+}
+
+// Record notes an unprompted reply in channelID, returning false if it would exceed the rate limit
+func (t *ChimeInTracker) Record(channelID string, now time.Time) bool {
+	if ok, _ := t.rate.Allow(channelID); !ok {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastChimed[channelID] = now
+	return true
+}
+
+// inQuietHours reports whether now falls within the configured quiet hours
+func (t *ChimeInTracker) inQuietHours(now time.Time) bool {
+	start, end, err := parseQuietHours(t.config.QuietHours)
+	if err != nil || start == end {
+		return false
+	}
+
+	local := now.In(t.location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	// The quiet period wraps past midnight
+	return minute >= start || minute < end
+}
+
+// parseQuietHours parses "HH:MM-HH:MM" into minutes after midnight. An empty string means no quiet hours.
+func parseQuietHours(quietHours string) (start, end int, err error) {
+	if quietHours == "" {
+		return 0, 0, nil
+	}
+
+	from, to, ok := strings.Cut(quietHours, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM-HH:MM, got %q", quietHours)
+	}
+	startTime, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start time %q", from)
+	}
+	endTime, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end time %q", to)
+	}
+	return startTime.Hour()*60 + startTime.Minute(), endTime.Hour()*60 + endTime.Minute(), nil
+}
+
+// considerChimingIn asks the classifier whether the bot should join the conversation in
+// channelID and, if so, posts an unprompted reply. It runs on the channel's request queue.
+func considerChimingIn(discord *discordgo.Session, guildID, channelID string) {
+	if !chimeIns.Ready(channelID, time.Now()) {
+		return
+	}
+
+	history := chatHistory.Get(channelID)
+	recent := history[max(0, len(history)-2*config.Bot.ChimeIn.AfterMessages):]
+
+	var decision chimeInDecision
+	err := grokClient.CompleteStruct(rootCtx, chimeInClassifierPrompt, formatTranscript(recent), &decision, CompletionOptions{
+		Model: config.Bot.ChimeIn.Model,
+	})
+	if err != nil {
+		log.Printf("Error classifying conversation in channel %s: %v", channelID, err)
+		return
+	}
+	if !decision.Respond || decision.Score < config.Bot.ChimeIn.MinScore {
+		return
+	}
+	if !chimeIns.Record(channelID, time.Now()) {
+		return
+	}
+	log.Printf("Chiming in to channel %s (score %.2f): %s", channelID, decision.Score, decision.Reason)
+
+	messages := make([]ChatMessage, 0, len(history)+2)
+	messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt(discord, channelID, "", "")})
+	messages = append(messages, history...)
+	messages = append(messages, ChatMessage{
+		Role:    "system",
+		Content: fmt.Sprintf("Nobody asked you, but you are joining the conversation because: %s. Keep it short and natural.", decision.Reason),
+	})
+
+	stopTyping := keepTyping(rootCtx, discord, channelID)
+	completion, err := grokClient.CreateChatCompletionDetailed(rootCtx, resolveImages(messages), withPersona(channelID, CompletionOptions{
+		ReasoningEffort: reasoningEffortFor(guildID, chimeInCommandName),
+		Search:          searchParametersFor(channelID),
+	}))
+	stopTyping()
+	if err != nil {
+		log.Printf("Error getting Grok response for chime-in: %v", err)
+		return
+	}
+	completion.Content = emojiCatalog.Repair(guildID, completion.Content)
+
+	content := completion.Content + formatCitations(completion.Citations)
+	reply, err := sendReply(discord, channelID, content, completion.ReasoningContent)
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+		return
+	}
+
+	assistantMessage := CreateTextMessage("assistant", completion.Content, "")
+	assistantMessage.MessageID = reply.ID
+	chatHistory.Append(channelID, assistantMessage)
+}
+
+// formatTranscript renders chat history as "[name]: text" lines for classification prompts
+func formatTranscript(messages []ChatMessage) string {
+	var transcript strings.Builder
+	for _, message := range messages {
+		name := message.Username
+		if message.Role == "assistant" {
+			name = "Grok"
+		}
+		if name == "" {
+			name = message.Role
+		}
+		fmt.Fprintf(&transcript, "[%s]: %s\n", name, messageText(message))
+	}
+	return transcript.String()
+}
@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// slashCommand pairs an application command definition with the handler that answers it
+type slashCommand struct {
+	definition *discordgo.ApplicationCommand
+	handler    func(discord *discordgo.Session, interaction *discordgo.InteractionCreate)
+}
+
+// slashCommands returns every slash command the bot provides
+func slashCommands() []slashCommand {
+	return []slashCommand{
+		imagineCommand(),
+		searchCommand(),
+		emojiCommand(),
+		personaCommand(),
+		memoryCommand(),
+		summarizeCommand(),
+		scheduleCommand(),
+		remindersCommand(),
+	}
+}
+
+// registerSlashCommands replaces the bot's global application commands with slashCommands
+func registerSlashCommands(discord *discordgo.Session) {
+	var definitions []*discordgo.ApplicationCommand
+	for _, command := range slashCommands() {
+		definitions = append(definitions, command.definition)
+	}
+
+	if _, err := discord.ApplicationCommandBulkOverwrite(discord.State.User.ID, "", definitions); err != nil {
+		log.Printf("Error registering slash commands: %v", err)
+		return
+	}
+	log.Printf("Registered %d slash commands", len(definitions))
+}
+
+// handleInteraction dispatches application command interactions to their handlers
+func handleInteraction(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	if interaction.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	name := interaction.ApplicationCommandData().Name
+	for _, command := range slashCommands() {
+		if command.definition.Name == name {
+			command.handler(discord, interaction)
+			return
+		}
+	}
+	log.Printf("Received unknown slash command %q", name)
+}
+
+// interactionUser returns the user who triggered an interaction, in a guild or a DM
+func interactionUser(interaction *discordgo.InteractionCreate) *discordgo.User {
+	if interaction.Member != nil && interaction.Member.User != nil {
+		return interaction.Member.User
+	}
+	return interaction.User
+}
+
+// respondEphemeral answers an interaction with a message only the invoking user can see
+func respondEphemeral(discord *discordgo.Session, interaction *discordgo.InteractionCreate, content string) {
+	err := discord.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error responding to interaction: %v", err)
+	}
+}
+
+// truncateList cuts a newline-separated list at a line break so it fits in one Discord message
+func truncateList(list string) string {
+	if len(list) <= MaxDiscordMessageLength {
+		return list
+	}
+	cut := strings.LastIndex(list[:MaxDiscordMessageLength-4], "\n")
+	return list[:max(cut, 0)] + "\n..."
+}
+
+// deferResponse acknowledges an interaction whose answer will take a while
+func deferResponse(discord *discordgo.Session, interaction *discordgo.InteractionCreate) error {
+	return discord.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+}
+
+// deferEphemeralResponse acknowledges an interaction whose answer will take a while and only
+// the invoking user should see
+func deferEphemeralResponse(discord *discordgo.Session, interaction *discordgo.InteractionCreate) error {
+	return discord.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// editResponse replaces a deferred interaction response with content
+func editResponse(discord *discordgo.Session, interaction *discordgo.InteractionCreate, content string) {
+	if _, err := discord.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("Error editing interaction response: %v", err)
+	}
+}
+
+// editResponseText replaces a deferred interaction response with a model answer, attaching
+// it as a markdown file when it is too long for a message
+func editResponseText(discord *discordgo.Session, interaction *discordgo.InteractionCreate, content string) (*discordgo.Message, error) {
+	edit := &discordgo.WebhookEdit{Content: &content}
+	if len(content) > config.Bot.MaxMessageSize {
+		if len(content) > MaxDiscordFileSize {
+			return nil, fmt.Errorf("response too large even for file upload (%d bytes)", len(content))
+		}
+		empty := ""
+		edit.Content = &empty
+		edit.Files = []*discordgo.File{{
+			Name:        markdownFilename(),
+			ContentType: "text/markdown",
+			Reader:      strings.NewReader(content),
+		}}
+	}
+	return discord.InteractionResponseEdit(interaction.Interaction, edit)
+}
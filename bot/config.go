@@ -2,6 +2,7 @@ package bot
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -22,23 +23,144 @@ type DiscordConfig struct {
 
 // GrokConfig holds Grok API-specific configuration
 type GrokConfig struct {
-	APIKey      string        `mapstructure:"api_key"`
-	BaseURL     string        `mapstructure:"base_url"`
-	Model       string        `mapstructure:"model"`
-	Temperature float64       `mapstructure:"temperature"`
-	MaxTokens   int           `mapstructure:"max_tokens"`
-	Timeout     time.Duration `mapstructure:"timeout"`
-	Stream      bool          `mapstructure:"stream"`
+	APIKey                 string            `mapstructure:"api_key"`
+	BaseURL                string            `mapstructure:"base_url"`
+	Model                  string            `mapstructure:"model"`
+	Temperature            float64           `mapstructure:"temperature"`
+	MaxTokens              int               `mapstructure:"max_tokens"`
+	Timeout                time.Duration     `mapstructure:"timeout"`
+	Stream                 bool              `mapstructure:"stream"`
+	ImageModel             string            `mapstructure:"image_model"`
+	ReasoningEffort        string            `mapstructure:"reasoning_effort"`
+	GuildReasoningEffort   map[string]string `mapstructure:"guild_reasoning_effort"`
+	CommandReasoningEffort map[string]string `mapstructure:"command_reasoning_effort"`
+	Search                 SearchConfig      `mapstructure:"search"`
+	EmbeddingModel         string            `mapstructure:"embedding_model"`
+}
+
+// SearchConfig holds live search configuration
+type SearchConfig struct {
+	Channels   []string `mapstructure:"channels"`
+	Sources    []string `mapstructure:"sources"`
+	MaxResults int      `mapstructure:"max_results"`
+}
+
+// TriggerConfig holds the rules that decide when a message addresses the bot
+type TriggerConfig struct {
+	Mentions           bool     `mapstructure:"mentions"`
+	RoleMentions       bool     `mapstructure:"role_mentions"`
+	Roles              []string `mapstructure:"roles"`
+	Keywords           []string `mapstructure:"keywords"`
+	Prefixes           []string `mapstructure:"prefixes"`
+	Replies            bool     `mapstructure:"replies"`
+	ChimeInProbability float64  `mapstructure:"chime_in_probability"`
+}
+
+// ChimeInConfig holds the settings for joining conversations unprompted
+type ChimeInConfig struct {
+	Channels      []string      `mapstructure:"channels"`
+	AfterMessages int           `mapstructure:"after_messages"`
+	Model         string        `mapstructure:"model"`
+	MinScore      float64       `mapstructure:"min_score"`
+	Cooldown      time.Duration `mapstructure:"cooldown"`
+	MaxMessages   int           `mapstructure:"max_messages"`
+	Window        time.Duration `mapstructure:"window"`
+	QuietHours    string        `mapstructure:"quiet_hours"`
+}
+
+// ArchiveConfig holds settings for retrieving older channel messages relevant to a question
+type ArchiveConfig struct {
+	Enabled          bool    `mapstructure:"enabled"`
+	MaxMessages      int     `mapstructure:"max_messages"`
+	BackfillMessages int     `mapstructure:"backfill_messages"`
+	Results          int     `mapstructure:"results"`
+	MinSimilarity    float64 `mapstructure:"min_similarity"`
+}
+
+// KnowledgeConfig holds settings for answering from documents ingested with `grok-bot ingest`
+type KnowledgeConfig struct {
+	Guilds        map[string]string `mapstructure:"guilds"` // guild ID -> knowledge base name
+	Results       int               `mapstructure:"results"`
+	ChunkSize     int               `mapstructure:"chunk_size"`
+	MinSimilarity float64           `mapstructure:"min_similarity"`
+}
+
+// SummarizeConfig holds settings for the /summarize command
+type SummarizeConfig struct {
+	MaxMessages int `mapstructure:"max_messages"`
+	ChunkSize   int `mapstructure:"chunk_size"`
+}
+
+// ScheduleConfig holds settings for prompts run on a schedule with /schedule
+type ScheduleConfig struct {
+	MaxJobs int `mapstructure:"max_jobs"`
+}
+
+// RemindersConfig holds settings for reminders set in natural language
+type RemindersConfig struct {
+	Model      string `mapstructure:"model"`
+	MaxPerUser int    `mapstructure:"max_per_user"`
+}
+
+// MemoryConfig holds settings for long-term facts the bot remembers about users
+type MemoryConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Model    string `mapstructure:"model"`
+	MaxFacts int    `mapstructure:"max_facts"`
+}
+
+// PersonaConfig describes a named personality the bot can take on in a channel
+type PersonaConfig struct {
+	Name          string   `mapstructure:"name"`
+	Description   string   `mapstructure:"description"`
+	SystemMessage string   `mapstructure:"system_message"`
+	Model         string   `mapstructure:"model"`
+	Temperature   *float64 `mapstructure:"temperature"`
+	Nickname      string   `mapstructure:"nickname"`
+	AvatarURL     string   `mapstructure:"avatar_url"`
 }
 
 // BotConfig holds bot behavior configuration
 type BotConfig struct {
-	MaxHistory           int    `mapstructure:"max_history"`
-	Verbose              bool   `mapstructure:"verbose"`
-	EnableEmojis         bool   `mapstructure:"enable_emojis"`
-	EnableHistory        bool   `mapstructure:"enable_history"`
-	MaxMessageSize       int    `mapstructure:"max_message_size"`
-	DefaultSystemMessage string `mapstructure:"default_system_message"`
+	MaxHistory              int               `mapstructure:"max_history"`
+	Verbose                 bool              `mapstructure:"verbose"`
+	EnableEmojis            bool              `mapstructure:"enable_emojis"`
+	EnableHistory           bool              `mapstructure:"enable_history"`
+	MaxMessageSize          int               `mapstructure:"max_message_size"`
+	DefaultSystemMessage    string            `mapstructure:"default_system_message"`
+	RegenerateOnEdit        bool              `mapstructure:"regenerate_on_edit"`
+	EditWindow              time.Duration     `mapstructure:"edit_window"`
+	MaxConcurrentRequests   int               `mapstructure:"max_concurrent_requests"`
+	MaxQueueDepth           int               `mapstructure:"max_queue_depth"`
+	ThinkingMessageAfter    time.Duration     `mapstructure:"thinking_message_after"`
+	BackfillConcurrency     int               `mapstructure:"backfill_concurrency"`
+	DataDir                 string            `mapstructure:"data_dir"`
+	EnableFileAttachments   bool              `mapstructure:"enable_file_attachments"`
+	MaxFileAttachmentSize   int               `mapstructure:"max_file_attachment_size"`
+	MaxAttachmentTextLength int               `mapstructure:"max_attachment_text_length"`
+	MaxHistoryImages        int               `mapstructure:"max_history_images"`
+	ImageCacheMemorySize    int64             `mapstructure:"image_cache_memory_size"`
+	ImageCacheDiskSize      int64             `mapstructure:"image_cache_disk_size"`
+	ImageCacheTTL           time.Duration     `mapstructure:"image_cache_ttl"`
+	MaxImageDimension       int               `mapstructure:"max_image_dimension"`
+	MaxImageBytes           int               `mapstructure:"max_image_bytes"`
+	EnableEmbedImages       bool              `mapstructure:"enable_embed_images"`
+	ImageQuotaPerUser       int               `mapstructure:"image_quota_per_user"`
+	ImageQuotaWindow        time.Duration     `mapstructure:"image_quota_window"`
+	ShowReasoning           string            `mapstructure:"show_reasoning"`
+	Triggers                TriggerConfig     `mapstructure:"triggers"`
+	Timezone                string            `mapstructure:"timezone"`
+	ChimeIn                 ChimeInConfig     `mapstructure:"chime_in"`
+	EmojiDescriptions       map[string]string `mapstructure:"emoji_descriptions"`
+	Personas                []PersonaConfig   `mapstructure:"personas"`
+	DefaultPersona          string            `mapstructure:"default_persona"`
+	WebhookReplies          bool              `mapstructure:"webhook_replies"`
+	Memory                  MemoryConfig      `mapstructure:"memory"`
+	Archive                 ArchiveConfig     `mapstructure:"archive"`
+	Knowledge               KnowledgeConfig   `mapstructure:"knowledge"`
+	Summarize               SummarizeConfig   `mapstructure:"summarize"`
+	Schedule                ScheduleConfig    `mapstructure:"schedule"`
+	Reminders               RemindersConfig   `mapstructure:"reminders"`
 }
 
 // ServerConfig holds web server configuration
@@ -54,21 +176,102 @@ func DefaultConfig() *Config {
 			Token: "",
 		},
 		Grok: GrokConfig{
-			APIKey:      "",
-			BaseURL:     "https://api.x.ai/v1",
-			Model:       "grok-4-fast",
-			Temperature: 0.5,
-			MaxTokens:   1000,
-			Timeout:     120 * time.Second,
-			Stream:      false,
+			APIKey:          "",
+			BaseURL:         "https://api.x.ai/v1",
+			Model:           "grok-4-fast",
+			Temperature:     0.5,
+			MaxTokens:       1000,
+			Timeout:         120 * time.Second,
+			Stream:          false,
+			ImageModel:      "grok-2-image",
+			ReasoningEffort: "",
+			Search: SearchConfig{
+				Channels:   []string{},
+				Sources:    []string{"web", "x"},
+				MaxResults: 10,
+			},
+			EmbeddingModel: "",
 		},
 		Bot: BotConfig{
-			MaxHistory:           100,
-			Verbose:              false,
-			EnableEmojis:         true,
-			EnableHistory:        true,
-			MaxMessageSize:       2000,
-			DefaultSystemMessage: getDefaultSystemMessage(),
+			MaxHistory:              100,
+			Verbose:                 false,
+			EnableEmojis:            true,
+			EnableHistory:           true,
+			MaxMessageSize:          2000,
+			DefaultSystemMessage:    getDefaultSystemMessage(),
+			RegenerateOnEdit:        false,
+			EditWindow:              5 * time.Minute,
+			MaxConcurrentRequests:   4,
+			MaxQueueDepth:           5,
+			ThinkingMessageAfter:    0,
+			BackfillConcurrency:     4,
+			DataDir:                 "data",
+			EnableFileAttachments:   true,
+			MaxFileAttachmentSize:   10 * 1024 * 1024,
+			MaxAttachmentTextLength: 100000,
+			MaxHistoryImages:        4,
+			ImageCacheMemorySize:    64 * 1024 * 1024,
+			ImageCacheDiskSize:      512 * 1024 * 1024,
+			ImageCacheTTL:           7 * 24 * time.Hour,
+			MaxImageDimension:       2048,
+			MaxImageBytes:           4 * 1024 * 1024,
+			EnableEmbedImages:       true,
+			ImageQuotaPerUser:       10,
+			ImageQuotaWindow:        24 * time.Hour,
+			ShowReasoning:           ReasoningDisplayOff,
+			Triggers: TriggerConfig{
+				Mentions:           true,
+				RoleMentions:       true,
+				Roles:              []string{},
+				Keywords:           []string{"@grok"},
+				Prefixes:           []string{},
+				Replies:            true,
+				ChimeInProbability: 0,
+			},
+			Timezone: "UTC",
+			ChimeIn: ChimeInConfig{
+				Channels:      []string{},
+				AfterMessages: 10,
+				Model:         "grok-3-mini",
+				MinScore:      0.7,
+				Cooldown:      30 * time.Minute,
+				MaxMessages:   5,
+				Window:        24 * time.Hour,
+				QuietHours:    "",
+			},
+			EmojiDescriptions: map[string]string{},
+			Personas:          []PersonaConfig{},
+			DefaultPersona:    "",
+			WebhookReplies:    false,
+			Memory: MemoryConfig{
+				Enabled:  false,
+				Model:    "grok-3-mini",
+				MaxFacts: 20,
+			},
+			Archive: ArchiveConfig{
+				Enabled:          false,
+				MaxMessages:      10000,
+				BackfillMessages: 1000,
+				Results:          5,
+				MinSimilarity:    0.3,
+			},
+			Knowledge: KnowledgeConfig{
+				Guilds:        map[string]string{},
+				Results:       4,
+				ChunkSize:     1500,
+				MinSimilarity: 0.3,
+			},
+			Summarize: SummarizeConfig{
+				MaxMessages: 1000,
+				ChunkSize:   12000,
+			},
+			Schedule: ScheduleConfig{
+				MaxJobs: 25,
+			},
+			Reminders: RemindersConfig{
+				Model:      "grok-3-mini",
+				MaxPerUser: 25,
+			},
 		},
 		Server: ServerConfig{
 			Port:    "8080",
@@ -107,12 +310,72 @@ func LoadConfig(configPath string) (*Config, error) {
 	viper.BindEnv("grok.max_tokens", "GROK_MAX_TOKENS")
 	viper.BindEnv("grok.timeout", "GROK_TIMEOUT")
 	viper.BindEnv("grok.stream", "GROK_STREAM")
+	viper.BindEnv("grok.image_model", "GROK_IMAGE_MODEL")
+	viper.BindEnv("grok.reasoning_effort", "GROK_REASONING_EFFORT")
+	viper.BindEnv("grok.search.channels", "GROK_SEARCH_CHANNELS")
+	viper.BindEnv("grok.search.sources", "GROK_SEARCH_SOURCES")
+	viper.BindEnv("grok.search.max_results", "GROK_SEARCH_MAX_RESULTS")
+	viper.BindEnv("grok.embedding_model", "GROK_EMBEDDING_MODEL")
 	viper.BindEnv("bot.max_history", "GROK_HISTORY_SIZE")
 	viper.BindEnv("bot.verbose", "GROK_VERBOSE")
 	viper.BindEnv("bot.enable_emojis", "GROK_ENABLE_EMOJIS")
 	viper.BindEnv("bot.enable_history", "GROK_ENABLE_HISTORY")
 	viper.BindEnv("bot.max_message_size", "GROK_MAX_MESSAGE_SIZE")
 	viper.BindEnv("bot.default_system_message", "GROK_DEFAULT_SYSTEM_MESSAGE")
+	viper.BindEnv("bot.regenerate_on_edit", "GROK_REGENERATE_ON_EDIT")
+	viper.BindEnv("bot.edit_window", "GROK_EDIT_WINDOW")
+	viper.BindEnv("bot.max_concurrent_requests", "GROK_MAX_CONCURRENT_REQUESTS")
+	viper.BindEnv("bot.max_queue_depth", "GROK_MAX_QUEUE_DEPTH")
+	viper.BindEnv("bot.thinking_message_after", "GROK_THINKING_MESSAGE_AFTER")
+	viper.BindEnv("bot.backfill_concurrency", "GROK_BACKFILL_CONCURRENCY")
+	viper.BindEnv("bot.data_dir", "GROK_DATA_DIR")
+	viper.BindEnv("bot.enable_file_attachments", "GROK_ENABLE_FILE_ATTACHMENTS")
+	viper.BindEnv("bot.max_file_attachment_size", "GROK_MAX_FILE_ATTACHMENT_SIZE")
+	viper.BindEnv("bot.max_attachment_text_length", "GROK_MAX_ATTACHMENT_TEXT_LENGTH")
+	viper.BindEnv("bot.max_history_images", "GROK_MAX_HISTORY_IMAGES")
+	viper.BindEnv("bot.image_cache_memory_size", "GROK_IMAGE_CACHE_MEMORY_SIZE")
+	viper.BindEnv("bot.image_cache_disk_size", "GROK_IMAGE_CACHE_DISK_SIZE")
+	viper.BindEnv("bot.image_cache_ttl", "GROK_IMAGE_CACHE_TTL")
+	viper.BindEnv("bot.max_image_dimension", "GROK_MAX_IMAGE_DIMENSION")
+	viper.BindEnv("bot.max_image_bytes", "GROK_MAX_IMAGE_BYTES")
+	viper.BindEnv("bot.enable_embed_images", "GROK_ENABLE_EMBED_IMAGES")
+	viper.BindEnv("bot.image_quota_per_user", "GROK_IMAGE_QUOTA_PER_USER")
+	viper.BindEnv("bot.image_quota_window", "GROK_IMAGE_QUOTA_WINDOW")
+	viper.BindEnv("bot.show_reasoning", "GROK_SHOW_REASONING")
+	viper.BindEnv("bot.triggers.mentions", "GROK_TRIGGER_MENTIONS")
+	viper.BindEnv("bot.triggers.role_mentions", "GROK_TRIGGER_ROLE_MENTIONS")
+	viper.BindEnv("bot.triggers.roles", "GROK_TRIGGER_ROLES")
+	viper.BindEnv("bot.triggers.keywords", "GROK_TRIGGER_KEYWORDS")
+	viper.BindEnv("bot.triggers.prefixes", "GROK_TRIGGER_PREFIXES")
+	viper.BindEnv("bot.triggers.replies", "GROK_TRIGGER_REPLIES")
+	viper.BindEnv("bot.triggers.chime_in_probability", "GROK_TRIGGER_CHIME_IN_PROBABILITY")
+	viper.BindEnv("bot.timezone", "GROK_TIMEZONE")
+	viper.BindEnv("bot.default_persona", "GROK_DEFAULT_PERSONA")
+	viper.BindEnv("bot.webhook_replies", "GROK_WEBHOOK_REPLIES")
+	viper.BindEnv("bot.archive.enabled", "GROK_ARCHIVE_ENABLED")
+	viper.BindEnv("bot.archive.max_messages", "GROK_ARCHIVE_MAX_MESSAGES")
+	viper.BindEnv("bot.archive.backfill_messages", "GROK_ARCHIVE_BACKFILL_MESSAGES")
+	viper.BindEnv("bot.archive.results", "GROK_ARCHIVE_RESULTS")
+	viper.BindEnv("bot.archive.min_similarity", "GROK_ARCHIVE_MIN_SIMILARITY")
+	viper.BindEnv("bot.knowledge.results", "GROK_KNOWLEDGE_RESULTS")
+	viper.BindEnv("bot.knowledge.chunk_size", "GROK_KNOWLEDGE_CHUNK_SIZE")
+	viper.BindEnv("bot.knowledge.min_similarity", "GROK_KNOWLEDGE_MIN_SIMILARITY")
+	viper.BindEnv("bot.summarize.max_messages", "GROK_SUMMARIZE_MAX_MESSAGES")
+	viper.BindEnv("bot.summarize.chunk_size", "GROK_SUMMARIZE_CHUNK_SIZE")
+	viper.BindEnv("bot.schedule.max_jobs", "GROK_SCHEDULE_MAX_JOBS")
+	viper.BindEnv("bot.reminders.model", "GROK_REMINDERS_MODEL")
+	viper.BindEnv("bot.reminders.max_per_user", "GROK_REMINDERS_MAX_PER_USER")
+	viper.BindEnv("bot.memory.enabled", "GROK_MEMORY_ENABLED")
+	viper.BindEnv("bot.memory.model", "GROK_MEMORY_MODEL")
+	viper.BindEnv("bot.memory.max_facts", "GROK_MEMORY_MAX_FACTS")
+	viper.BindEnv("bot.chime_in.channels", "GROK_CHIME_IN_CHANNELS")
+	viper.BindEnv("bot.chime_in.after_messages", "GROK_CHIME_IN_AFTER_MESSAGES")
+	viper.BindEnv("bot.chime_in.model", "GROK_CHIME_IN_MODEL")
+	viper.BindEnv("bot.chime_in.min_score", "GROK_CHIME_IN_MIN_SCORE")
+	viper.BindEnv("bot.chime_in.cooldown", "GROK_CHIME_IN_COOLDOWN")
+	viper.BindEnv("bot.chime_in.max_messages", "GROK_CHIME_IN_MAX_MESSAGES")
+	viper.BindEnv("bot.chime_in.window", "GROK_CHIME_IN_WINDOW")
+	viper.BindEnv("bot.chime_in.quiet_hours", "GROK_CHIME_IN_QUIET_HOURS")
 	viper.BindEnv("server.port", "GROK_BOT_SERVER_PORT")
 	viper.BindEnv("server.enabled", "GROK_BOT_SERVER_ENABLED")
 
@@ -157,12 +420,175 @@ func (c *Config) Validate() error {
 	if c.Grok.MaxTokens <= 0 {
 		return fmt.Errorf("grok max tokens must be greater than 0")
 	}
+	if !isValidReasoningEffort(c.Grok.ReasoningEffort) {
+		return fmt.Errorf("grok reasoning effort must be empty, \"low\" or \"high\"")
+	}
+	for key, effort := range c.Grok.GuildReasoningEffort {
+		if !isValidReasoningEffort(effort) {
+			return fmt.Errorf("grok reasoning effort for guild %s must be empty, \"low\" or \"high\"", key)
+		}
+	}
+	for key, effort := range c.Grok.CommandReasoningEffort {
+		if !isValidReasoningEffort(effort) {
+			return fmt.Errorf("grok reasoning effort for command %s must be empty, \"low\" or \"high\"", key)
+		}
+	}
+	if len(c.Grok.Search.Sources) == 0 {
+		return fmt.Errorf("grok search sources must not be empty")
+	}
+	for _, source := range c.Grok.Search.Sources {
+		if !isValidSearchSource(source) {
+			return fmt.Errorf("grok search source %q must be one of web, x or news", source)
+		}
+	}
+	if c.Grok.Search.MaxResults <= 0 {
+		return fmt.Errorf("grok search max results must be greater than 0")
+	}
 	if c.Bot.MaxHistory <= 0 {
 		return fmt.Errorf("bot max history must be greater than 0")
 	}
 	if c.Bot.MaxMessageSize <= 0 {
 		return fmt.Errorf("bot max message size must be greater than 0")
 	}
+	if c.Bot.MaxConcurrentRequests <= 0 {
+		return fmt.Errorf("bot max concurrent requests must be greater than 0")
+	}
+	if c.Bot.MaxQueueDepth <= 0 {
+		return fmt.Errorf("bot max queue depth must be greater than 0")
+	}
+	if c.Bot.BackfillConcurrency <= 0 {
+		return fmt.Errorf("bot backfill concurrency must be greater than 0")
+	}
+	if c.Bot.DataDir == "" {
+		return fmt.Errorf("bot data dir is required")
+	}
+	if c.Bot.EnableFileAttachments && (c.Bot.MaxFileAttachmentSize <= 0 || c.Bot.MaxAttachmentTextLength <= 0) {
+		return fmt.Errorf("bot file attachment limits must be greater than 0")
+	}
+	if c.Bot.MaxHistoryImages < 0 {
+		return fmt.Errorf("bot max history images must not be negative")
+	}
+	if c.Bot.ImageCacheMemorySize <= 0 || c.Bot.ImageCacheDiskSize <= 0 {
+		return fmt.Errorf("bot image cache sizes must be greater than 0")
+	}
+	if c.Bot.MaxImageDimension <= 0 || c.Bot.MaxImageBytes <= 0 {
+		return fmt.Errorf("bot max image dimension and bytes must be greater than 0")
+	}
+	if c.Bot.ImageQuotaPerUser > 0 && c.Bot.ImageQuotaWindow <= 0 {
+		return fmt.Errorf("bot image quota window must be greater than 0")
+	}
+	switch c.Bot.ShowReasoning {
+	case ReasoningDisplayOff, ReasoningDisplaySpoiler, ReasoningDisplayFile:
+	default:
+		return fmt.Errorf("bot show reasoning must be \"off\", \"spoiler\" or \"file\"")
+	}
+	if c.Bot.Triggers.ChimeInProbability < 0 || c.Bot.Triggers.ChimeInProbability > 1 {
+		return fmt.Errorf("bot trigger chime in probability must be between 0 and 1")
+	}
+	if err := validatePromptTemplate(c.Bot.DefaultSystemMessage); err != nil {
+		return fmt.Errorf("bot default system message is not a valid template (%s): %w", describePromptVariables(), err)
+	}
+	if err := c.validatePersonas(); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(c.Bot.Timezone); err != nil {
+		return fmt.Errorf("bot timezone is invalid: %w", err)
+	}
+	if len(c.Bot.ChimeIn.Channels) > 0 {
+		if c.Bot.ChimeIn.AfterMessages <= 0 {
+			return fmt.Errorf("bot chime in after messages must be greater than 0")
+		}
+		if c.Bot.ChimeIn.MinScore < 0 || c.Bot.ChimeIn.MinScore > 1 {
+			return fmt.Errorf("bot chime in min score must be between 0 and 1")
+		}
+		if c.Bot.ChimeIn.MaxMessages > 0 && c.Bot.ChimeIn.Window <= 0 {
+			return fmt.Errorf("bot chime in window must be greater than 0")
+		}
+		if _, _, err := parseQuietHours(c.Bot.ChimeIn.QuietHours); err != nil {
+			return fmt.Errorf("bot chime in quiet hours are invalid: %w", err)
+		}
+	}
+	if c.Bot.Archive.Enabled {
+		if c.Bot.Archive.MaxMessages <= 0 {
+			return fmt.Errorf("bot archive max messages must be greater than 0")
+		}
+		if c.Bot.Archive.BackfillMessages < 0 {
+			return fmt.Errorf("bot archive backfill messages must not be negative")
+		}
+		if c.Bot.Archive.Results <= 0 {
+			return fmt.Errorf("bot archive results must be greater than 0")
+		}
+		if c.Bot.Archive.MinSimilarity < 0 || c.Bot.Archive.MinSimilarity > 1 {
+			return fmt.Errorf("bot archive min similarity must be between 0 and 1")
+		}
+	}
+	for guildID, name := range c.Bot.Knowledge.Guilds {
+		if !knowledgeNamePattern.MatchString(name) {
+			return fmt.Errorf("bot knowledge base %q for guild %s must be lowercase letters, digits, - and _", name, guildID)
+		}
+	}
+	if c.Bot.Knowledge.Results <= 0 {
+		return fmt.Errorf("bot knowledge results must be greater than 0")
+	}
+	if c.Bot.Knowledge.ChunkSize < 200 {
+		return fmt.Errorf("bot knowledge chunk size must be at least 200")
+	}
+	if c.Bot.Knowledge.MinSimilarity < 0 || c.Bot.Knowledge.MinSimilarity > 1 {
+		return fmt.Errorf("bot knowledge min similarity must be between 0 and 1")
+	}
+	if c.Bot.Summarize.MaxMessages <= 0 {
+		return fmt.Errorf("bot summarize max messages must be greater than 0")
+	}
+	if c.Bot.Summarize.ChunkSize < 1000 {
+		return fmt.Errorf("bot summarize chunk size must be at least 1000")
+	}
+	if c.Bot.Schedule.MaxJobs <= 0 {
+		return fmt.Errorf("bot schedule max jobs must be greater than 0")
+	}
+	if c.Bot.Reminders.MaxPerUser <= 0 {
+		return fmt.Errorf("bot reminders max per user must be greater than 0")
+	}
+	if c.Bot.Memory.Enabled && c.Bot.Memory.MaxFacts <= 0 {
+		return fmt.Errorf("bot memory max facts must be greater than 0")
+	}
+	if c.Bot.RegenerateOnEdit && c.Bot.EditWindow <= 0 {
+		return fmt.Errorf("bot edit window must be greater than 0 when regenerate on edit is enabled")
+	}
+	return nil
+}
+
+// validatePersonas checks that personas have unique names, valid templates and temperatures,
+// and that the default persona exists
+func (c *Config) validatePersonas() error {
+	if len(c.Bot.Personas) > maxPersonaChoices {
+		return fmt.Errorf("bot personas are limited to %d", maxPersonaChoices)
+	}
+
+	names := make(map[string]bool)
+	for _, persona := range c.Bot.Personas {
+		if persona.Name == "" {
+			return fmt.Errorf("bot persona name is required")
+		}
+		key := strings.ToLower(persona.Name)
+		if names[key] {
+			return fmt.Errorf("bot persona %q is defined more than once", persona.Name)
+		}
+		names[key] = true
+
+		if persona.SystemMessage == "" {
+			return fmt.Errorf("bot persona %q needs a system message", persona.Name)
+		}
+		if err := validatePromptTemplate(persona.SystemMessage); err != nil {
+			return fmt.Errorf("bot persona %q system message is not a valid template (%s): %w", persona.Name, describePromptVariables(), err)
+		}
+		if persona.Temperature != nil && (*persona.Temperature < 0 || *persona.Temperature > 2) {
+			return fmt.Errorf("bot persona %q temperature must be between 0 and 2", persona.Name)
+		}
+	}
+
+	if c.Bot.DefaultPersona != "" && !names[strings.ToLower(c.Bot.DefaultPersona)] {
+		return fmt.Errorf("bot default persona %q is not defined in bot.personas", c.Bot.DefaultPersona)
+	}
 	return nil
 }
 
@@ -177,63 +603,5 @@ func getDefaultSystemMessage() string {
 
 IMPORTANT: In the conversation history, user messages are formatted as "[Username]: message content" to help you understand who said what. You can reference users by name when appropriate. Assistant messages are your previous responses.
 
-When appropriate, you may use these server emojis (only if they exist in the current server). Use sparingly and contextually:
-
-<:FeelsEvilMan:734304991682232331> – mischievous / evil grin – for playful plotting.
-<:FeelsLaunchWeek:734305000347402310> – excitement / hype – for launches and news.
-<:FeelsOkayMan:734305008870359061> – content / accepting – it's fine, not great.
-<:PepeRaugh:734305166429519883> – exaggerated laugh – goofy hilarious moments.
-<:monkaHmm:734305212587835392> – skeptical / thinking / unsure.
-<:monkaS:734305295014297660> – anxious / nervous – intense or scary.
-<:PeepoHappy:734305400664359002> – pure joy – wholesome happiness.
-<:PepePoint:734305443945381938> – pointing – draw attention to something.
-<:PepeGive:734305636149493863> – offering / kindness.
-<:peeposalute:734305772015583252> – salute / respect.
-<:CringeChamp:734305931545804883> – cringe reaction – embarrassing moments.
-<:KEKW:734305966190887012> – laughing hard – hysterical laughter.
-<:Whoa:734306032758554625> – surprised / impressed.
-<:ReallyPal:734306104636342293> – sarcastic disbelief – "really?"
-<:PepeHardhat:734306379656986647> – construction / working – building or doing work.
-<:SmoothBrain:734307118458601493> – dumb moment – playful jab at not thinking.
-<:peepoGlare:734308800072384625> – judging / glaring – disapproval.
-<:tbh:734309542573244497> – honesty / "to be honest".
-<:PepeSimp:734312446633967636> – simping – infatuated or admiring.
-<:waitwhat:736124216033804329> – confused / disbelief – "wait… what??"
-<:SMILERS:747581716566376488> – forced smile – awkward positivity.
-<:WhatSheSees:747991898962002010> – POV meme – humorous situations.
-<:PepeSmile:753913643212734474> – genuine happiness – warm response.
-<:MilkMe:761785362539741184> – weird / suggestive humor – meme context only.
-<:peepoS:805018260461846548> – sad / disappointed.
-<:KEKPOPCORN:805023229769023518> – watching drama – observing chaos or gossip.
-<:LFG:805023270960889906> – hype – "Let's F***ing Go".
-<:GAMINGHARDCORE:805036362675781663> – intense gaming / hype.
-<:PogO:810377168587587594> – shocked / hype – "POG".
-<:WICKED:811480967603945503> – hype / wicked cool.
-<:SmogO:817230985715384351> – shocked / weird reaction.
-<:KEKL:821725755020935168> – laughing / kek variant.
-<:FeelsOldMan:827360929254473768> – nostalgia / feeling old.
-<:yeet:840771085817479168> – throwing / excitement.
-<:pepeLFG:840827942497681418> – LFG (Pepe version).
-<:KEK:845891104914014272> – laughing / meme reaction.
-<:yeehaw:845900394634805248> – cowboy / yeehaw energy.
-<:bald:848093898172661780> – bald meme – joking insult.
-<:clueless:955263155720839168> – naive / unaware.
-<:Handshakege:1089337603423215729> – handshake – agreement / teamwork.
-<:BirdStare:1090152526462062623> – staring / awkward silence.
-<:BirdQuestion:1097031900805222421> – confused / curious.
-<:susW:1115349088519602356> – "sus" / suspicious behavior.
-<:JOEVER:1119325324912623758> – "it's over" – dramatic or joking.
-<:YOUCANT:1137498477044187156> – denial / refusal.
-<:Pointless:1140321867174002749> – stating something is pointless.
-<:Smugjak:1157119284678496346> – smug expression – gloating / self-satisfaction.
-<:soymouth:1157366984451833997> – soyjak open-mouth – overexcitement.
-<:lookingR:1159213772104286258> / <:lookingL:1159214410317967391> – looking right/left – hinting.
-<:stare:1161771733829820426> – neutral stare – awkward or serious.
-<:okk:1162013143845838961> – "ok" – neutral acknowledgment.
-<:lulWut:1172251873131114526> – confused humor – "lol what?"
-<:CAUGHT:1183915104669020331> – caught doing something.
-<:sir:1206806163279052870> – polite / mock formality.
-<:CHAD:1222721809393385585> – alpha / confident energy.
-<:INSANITY:1223028653827297351> – shock / extreme reaction.
-<:oh:1223683806998036620> – simple "oh" – realization or silence.`
+{{if .GuildName}}You're in #{{.ChannelName}} on the {{.GuildName}} server.{{end}} It is currently {{.Time}} on {{.Date}}.`
 }
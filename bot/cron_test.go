@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"0 9 * *",
+		"0 9 * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@fortnightly",
+	}
+	for _, spec := range tests {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) should fail", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 is a Monday
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		want time.Time
+	}{
+		{"daily macro", "@daily", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"macro in other case", "@Hourly", time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"monthly macro", "@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"minute step", "*/15 * * * *", time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"step from offset", "5/20 * * * *", time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)},
+		{"weekdays", "0 9 * * 1-5", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"list", "30 8,17 * * *", time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)},
+		{"sunday as 0", "0 12 * * 0", time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 12 * * 7", time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)},
+		{"saturday to sunday range", "0 12 * * 6-7", time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)},
+		{"both days restricted match either", "0 0 15 * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"both days restricted, day of month first", "0 0 3 * 5", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"starred day of month must match both", "0 0 */2 * 1", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"starred day of week must match both", "0 0 13 * */7", time.Date(2024, 10, 13, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) failed: %v", tt.spec, err)
+			}
+			if got := schedule.Next(start); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	schedule, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron failed: %v", err)
+	}
+	next := schedule.Next(time.Date(2024, 1, 1, 10, 0, 0, 0, location))
+	if want := time.Date(2024, 1, 2, 9, 0, 0, 0, location); !next.Equal(want) || next.Location() != location {
+		t.Errorf("Next = %v, want %v", next, want)
+	}
+}
+
+func TestCronDayMatches(t *testing.T) {
+	monday1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tuesday2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	monday8 := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		day  time.Time
+		want bool
+	}{
+		{"0 0 1 * 1", monday1, true},
+		{"0 0 2 * 1", tuesday2, true},
+		{"0 0 2 * 1", monday8, true},
+		{"0 0 1 * 3", tuesday2, false},
+		{"0 0 */2 * 1", monday1, true},
+		{"0 0 */2 * 1", monday8, false},
+		{"0 0 */2 * 1", tuesday2, false},
+		{"0 0 * * 1", tuesday2, false},
+		{"0 0 1 * *", monday8, false},
+		{"0 0 * * *", tuesday2, true},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) failed: %v", tt.spec, err)
+		}
+		if got := schedule.dayMatches(tt.day); got != tt.want {
+			t.Errorf("%q on %s: got %v, want %v", tt.spec, tt.day.Format("Mon Jan 2"), got, tt.want)
+		}
+	}
+}
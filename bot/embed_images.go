@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// stickerURLFormat is the CDN URL of a sticker image by ID and extension
+const stickerURLFormat = "https://media.discordapp.net/stickers/%s.%s"
+
+// extractImageURLsFromEmbeds collects the images and thumbnails of message embeds, such as
+// pasted image links and GIF previews, and stores them in the image cache
+func extractImageURLsFromEmbeds(embeds []*discordgo.MessageEmbed) []string {
+	if !config.Bot.EnableEmbedImages {
+		return nil
+	}
+
+	var imageURLs []string
+	seen := make(map[string]bool)
+
+	for _, embed := range embeds {
+		if embed == nil {
+			continue
+		}
+
+		var candidates []string
+		if embed.Image != nil {
+			candidates = append(candidates, preferProxyURL(embed.Image.ProxyURL, embed.Image.URL))
+		}
+		if embed.Thumbnail != nil {
+			candidates = append(candidates, preferProxyURL(embed.Thumbnail.ProxyURL, embed.Thumbnail.URL))
+		}
+
+		for _, url := range candidates {
+			if url == "" || seen[url] {
+				continue
+			}
+			seen[url] = true
+
+			ref, err := cacheImage(url, "embed image")
+			if err != nil {
+				log.Printf("Failed to process embed image %s: %v", url, err)
+				continue
+			}
+			imageURLs = append(imageURLs, ref)
+		}
+	}
+
+	return imageURLs
+}
+
+// extractImageURLsFromStickers downloads the images of message stickers and stores them in
+// the image cache. Lottie stickers are vector animations and are skipped.
+func extractImageURLsFromStickers(stickers []*discordgo.StickerItem) []string {
+	if !config.Bot.EnableEmbedImages {
+		return nil
+	}
+
+	var imageURLs []string
+
+	for _, sticker := range stickers {
+		if sticker == nil {
+			continue
+		}
+
+		var ext string
+		switch sticker.FormatType {
+		case discordgo.StickerFormatTypePNG, discordgo.StickerFormatTypeAPNG:
+			ext = "png"
+		case discordgo.StickerFormatTypeGIF:
+			ext = "gif"
+		default:
+			continue
+		}
+
+		ref, err := cacheImage(fmt.Sprintf(stickerURLFormat, sticker.ID, ext), "sticker "+sticker.Name)
+		if err != nil {
+			log.Printf("Failed to process sticker %s: %v", sticker.Name, err)
+			continue
+		}
+		imageURLs = append(imageURLs, ref)
+	}
+
+	return imageURLs
+}
+
+// preferProxyURL returns the Discord media proxy URL when available, since it serves
+// external images from Discord's CDN
+func preferProxyURL(proxyURL, url string) string {
+	if proxyURL != "" {
+		return proxyURL
+	}
+	return url
+}
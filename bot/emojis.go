@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// emojiDescriptionsFile is the name of the persisted emoji descriptions inside bot.data_dir
+const emojiDescriptionsFile = "emoji_descriptions.json"
+
+// maxCatalogEmojis caps how many emojis are listed in the system prompt; described emojis come first
+const maxCatalogEmojis = 100
+
+// emojiTokenPattern matches custom emoji tags like <:name:id> or <a:name:id> and :name: shortcodes
+var emojiTokenPattern = regexp.MustCompile(`<a?:\w{2,32}:\d+>|:\w{2,32}:`)
+
+// emojiTagPattern splits a custom emoji tag into its name and ID
+var emojiTagPattern = regexp.MustCompile(`^<a?:(\w{2,32}):(\d+)>$`)
+
+// EmojiCatalog tracks each guild's custom emojis and the descriptions admins gave them
+type EmojiCatalog struct {
+	mu           sync.RWMutex
+	guilds       map[string][]*discordgo.Emoji
+	descriptions map[string]string // emoji ID -> description set with /emoji describe
+	path         string
+}
+
+// NewEmojiCatalog constructs an EmojiCatalog persisting descriptions to path
+func NewEmojiCatalog(path string) *EmojiCatalog {
+	return &EmojiCatalog{
+		guilds:       make(map[string][]*discordgo.Emoji),
+		descriptions: make(map[string]string),
+		path:         path,
+	}
+}
+
+// Load reads persisted emoji descriptions, if any
+func (c *EmojiCatalog) Load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := readJSONFile(c.path, &c.descriptions)
+	if c.descriptions == nil {
+		c.descriptions = make(map[string]string)
+	}
+	return err
+}
+
+// SetGuild replaces the known emojis of a guild
+func (c *EmojiCatalog) SetGuild(guildID string, emojis []*discordgo.Emoji) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.guilds[guildID] = emojis
+}
+
+// Describe sets or, with an empty description, clears the meaning of an emoji and persists it
+func (c *EmojiCatalog) Describe(emojiID, description string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous, existed := c.descriptions[emojiID]
+	if description == "" {
+		delete(c.descriptions, emojiID)
+	} else {
+		c.descriptions[emojiID] = description
+	}
+	if err := writeJSONFile(c.path, c.descriptions); err != nil {
+		// Keep memory in line with what is saved
+		if existed {
+			c.descriptions[emojiID] = previous
+		} else {
+			delete(c.descriptions, emojiID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Find looks up a guild emoji by tag, ID or name
+func (c *EmojiCatalog) Find(guildID, query string) *discordgo.Emoji {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query = strings.TrimSpace(query)
+	if match := emojiTagPattern.FindStringSubmatch(query); match != nil {
+		return c.findLocked(guildID, match[2])
+	}
+	return c.findLocked(guildID, strings.Trim(query, ":"))
+}
+
+// findLocked looks up a guild emoji by ID, exact name or case-insensitive name
+func (c *EmojiCatalog) findLocked(guildID, query string) *discordgo.Emoji {
+	var folded *discordgo.Emoji
+	for _, emoji := range c.guilds[guildID] {
+		if emoji.ID == query || emoji.Name == query {
+			return emoji
+		}
+		if folded == nil && strings.EqualFold(emoji.Name, query) {
+			folded = emoji
+		}
+	}
+	return folded
+}
+
+// descriptionLocked returns an emoji's description, preferring one set with /emoji describe over config
+func (c *EmojiCatalog) descriptionLocked(emojiID string) string {
+	if description, ok := c.descriptions[emojiID]; ok {
+		return description
+	}
+	return config.Bot.EmojiDescriptions[emojiID]
+}
+
+// Entries returns a guild's usable emojis with their descriptions, described emojis first
+func (c *EmojiCatalog) Entries(guildID string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	type entry struct {
+		emoji       *discordgo.Emoji
+		description string
+	}
+	var entries []entry
+	for _, emoji := range c.guilds[guildID] {
+		if emoji.Available {
+			entries = append(entries, entry{emoji, c.descriptionLocked(emoji.ID)})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].description != "" && entries[j].description == ""
+	})
+
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		line := e.emoji.MessageFormat()
+		if e.description != "" {
+			line += " – " + e.description
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Prompt returns the emoji section of the system prompt for a guild, or "" if it has no emojis
+func (c *EmojiCatalog) Prompt(guildID string) string {
+	entries := c.Entries(guildID)
+	if len(entries) == 0 {
+		return ""
+	}
+	if len(entries) > maxCatalogEmojis {
+		entries = entries[:maxCatalogEmojis]
+	}
+	return "When appropriate, you may use these server emojis. Use sparingly and contextually, and copy the tags exactly:\n\n" + strings.Join(entries, "\n")
+}
+
+// Repair fixes custom emoji tags in a response for a guild: tags whose name exists in the
+// guild get the right ID, unknown tags are removed and :name: shortcodes of guild emojis become
+// tags. Responses outside a known guild are left alone.
+func (c *EmojiCatalog) Repair(guildID, content string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.guilds[guildID]; !ok {
+		return content
+	}
+
+	return emojiTokenPattern.ReplaceAllStringFunc(content, func(token string) string {
+		if match := emojiTagPattern.FindStringSubmatch(token); match != nil {
+			if emoji := c.findLocked(guildID, match[2]); emoji != nil {
+				return emoji.MessageFormat()
+			}
+			if emoji := c.findLocked(guildID, match[1]); emoji != nil {
+				return emoji.MessageFormat()
+			}
+			return ""
+		}
+
+		name := strings.Trim(token, ":")
+		for _, emoji := range c.guilds[guildID] {
+			if emoji.Name == name {
+				return emoji.MessageFormat()
+			}
+		}
+		return token
+	})
+}
+
+// handleGuildCreate records a guild's emojis when the bot starts or joins it
+func handleGuildCreate(discord *discordgo.Session, event *discordgo.GuildCreate) {
+	emojiCatalog.SetGuild(event.ID, event.Emojis)
+}
+
+// handleGuildEmojisUpdate refreshes a guild's emojis when they change
+func handleGuildEmojisUpdate(discord *discordgo.Session, event *discordgo.GuildEmojisUpdate) {
+	emojiCatalog.SetGuild(event.GuildID, event.Emojis)
+}
+
+// emojiCommand defines the /emoji slash command for annotating the guild's emojis
+func emojiCommand() slashCommand {
+	permissions := int64(discordgo.PermissionManageGuildExpressions)
+	dmPermission := false
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:                     "emoji",
+			Description:              "Manage the server emojis the bot knows about",
+			DefaultMemberPermissions: &permissions,
+			DMPermission:             &dmPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "describe",
+					Description: "Tell the bot what an emoji means",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "emoji",
+							Description: "The emoji or its name",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "meaning",
+							Description: "When to use it; leave empty to clear",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the emojis the bot may use here",
+				},
+			},
+		},
+		handler: handleEmojiCommand,
+	}
+}
+
+// handleEmojiCommand answers /emoji describe and /emoji list
+func handleEmojiCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	subcommand := interaction.ApplicationCommandData().Options[0]
+
+	switch subcommand.Name {
+	case "describe":
+		var query, meaning string
+		for _, option := range subcommand.Options {
+			switch option.Name {
+			case "emoji":
+				query = option.StringValue()
+			case "meaning":
+				meaning = strings.TrimSpace(option.StringValue())
+			}
+		}
+
+		emoji := emojiCatalog.Find(interaction.GuildID, query)
+		if emoji == nil {
+			respondEphemeral(discord, interaction, fmt.Sprintf("I couldn't find an emoji called %q in this server.", query))
+			return
+		}
+		if err := emojiCatalog.Describe(emoji.ID, meaning); err != nil {
+			log.Printf("Error saving emoji description: %v", err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		if meaning == "" {
+			respondEphemeral(discord, interaction, fmt.Sprintf("Cleared the meaning of %s.", emoji.MessageFormat()))
+		} else {
+			respondEphemeral(discord, interaction, fmt.Sprintf("%s now means: %s", emoji.MessageFormat(), meaning))
+		}
+
+	case "list":
+		entries := emojiCatalog.Entries(interaction.GuildID)
+		if len(entries) == 0 {
+			respondEphemeral(discord, interaction, "This server has no custom emojis.")
+			return
+		}
+		list := strings.Join(entries, "\n")
+		if !config.Bot.EnableEmojis {
+			list = "Emoji use is disabled in the bot config.\n\n" + list
+		}
+		respondEphemeral(discord, interaction, truncateList(list))
+	}
+}
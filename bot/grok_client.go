@@ -2,17 +2,22 @@ package bot
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 // GrokClient handles communication with XAI's Grok API
 type GrokClient struct {
 	Config *GrokConfig
 	Client *http.Client
+
+	usageMu sync.Mutex
+	usage   UsageTotals
 }
 
 // ContentItem represents a single content item in a multimodal message
@@ -26,18 +31,74 @@ type ContentItem struct {
 
 // ChatMessage represents a message in the chat completion request
 type ChatMessage struct {
-	Role     string      `json:"role"`
-	Content  any `json:"content"`            // Can be string or []ContentItem for multimodal
-	Username string      `json:"username,omitempty"` // Optional username for context
+	Role      string `json:"role"`
+	Content   any    `json:"content"`            // Can be string or []ContentItem for multimodal
+	Username  string `json:"username,omitempty"` // Optional username for context
+	MessageID string `json:"-"`                  // Discord message ID this entry came from, if any
+	UserID    string `json:"-"`                  // Discord user ID of the author of a user message, if known
 }
 
 // ChatCompletionRequest represents the request payload for chat completions
 type ChatCompletionRequest struct {
-	Model       string        `json:"model"`
-	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature,omitempty"`
-	MaxTokens   int           `json:"max_tokens,omitempty"`
-	Stream      bool          `json:"stream,omitempty"`
+	Model            string            `json:"model"`
+	Messages         []ChatMessage     `json:"messages"`
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"max_tokens,omitempty"`
+	Stream           bool              `json:"stream,omitempty"`
+	ResponseFormat   *ResponseFormat   `json:"response_format,omitempty"`
+	ReasoningEffort  string            `json:"reasoning_effort,omitempty"`
+	SearchParameters *SearchParameters `json:"search_parameters,omitempty"`
+}
+
+// ResponseFormat constrains the shape of a chat completion, e.g. to JSON matching a schema
+type ResponseFormat struct {
+	Type       string            `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat names a JSON schema the response must follow
+type JSONSchemaFormat struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+	Strict bool           `json:"strict,omitempty"`
+}
+
+// CompletionOptions adjusts a single chat completion request beyond the client configuration
+type CompletionOptions struct {
+	Model           string   // Overrides the configured model when set
+	Temperature     *float64 // Overrides the configured temperature when set
+	ResponseFormat  *ResponseFormat
+	ReasoningEffort string            // Overrides the configured reasoning effort when set
+	Search          *SearchParameters // Enables live search when set
+}
+
+// Completion is a chat completion answer together with the model's reasoning trace, if any
+type Completion struct {
+	Content          string
+	ReasoningContent string
+	Citations        []string // Source URLs consulted by live search
+	Usage            Usage
+}
+
+// Usage reports the tokens consumed by a chat completion
+type Usage struct {
+	PromptTokens            int `json:"prompt_tokens"`
+	CompletionTokens        int `json:"completion_tokens"`
+	TotalTokens             int `json:"total_tokens"`
+	NumSourcesUsed          int `json:"num_sources_used"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// UsageTotals accumulates token usage across all chat completions made by a client
+type UsageTotals struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	ReasoningTokens  int `json:"reasoning_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	SourcesUsed      int `json:"sources_used"`
 }
 
 // ChatCompletionResponse represents the response from the chat completions API
@@ -49,16 +110,14 @@ type ChatCompletionResponse struct {
 	Choices []struct {
 		Index   int `json:"index"`
 		Message struct {
-			Role    string      `json:"role"`
-			Content interface{} `json:"content"` // Can be string or []ContentItem for multimodal
+			Role             string      `json:"role"`
+			Content          interface{} `json:"content"` // Can be string or []ContentItem for multimodal
+			ReasoningContent string      `json:"reasoning_content,omitempty"`
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage     Usage    `json:"usage"`
+	Citations []string `json:"citations,omitempty"`
 }
 
 // XAIError represents an error response from the XAI API
@@ -82,6 +141,28 @@ func NewGrokClient(config *GrokConfig) *GrokClient {
 
 // CreateChatCompletion sends a chat completion request to the XAI API
 func (g *GrokClient) CreateChatCompletion(messages []ChatMessage) (string, error) {
+	return g.CreateChatCompletionContext(context.Background(), messages)
+}
+
+// CreateChatCompletionContext sends a chat completion request to the XAI API,
+// aborting it when ctx is cancelled
+func (g *GrokClient) CreateChatCompletionContext(ctx context.Context, messages []ChatMessage) (string, error) {
+	return g.CreateChatCompletionWithOptions(ctx, messages, CompletionOptions{})
+}
+
+// CreateChatCompletionWithOptions sends a chat completion request to the XAI API with
+// per-request options, aborting it when ctx is cancelled
+func (g *GrokClient) CreateChatCompletionWithOptions(ctx context.Context, messages []ChatMessage, opts CompletionOptions) (string, error) {
+	completion, err := g.CreateChatCompletionDetailed(ctx, messages, opts)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+// CreateChatCompletionDetailed sends a chat completion request to the XAI API and returns the
+// answer along with its reasoning trace and token usage
+func (g *GrokClient) CreateChatCompletionDetailed(ctx context.Context, messages []ChatMessage, opts CompletionOptions) (*Completion, error) {
 	// Format messages with usernames for context
 	formattedMessages := make([]ChatMessage, len(messages))
 	for i, msg := range messages {
@@ -92,12 +173,14 @@ func (g *GrokClient) CreateChatCompletion(messages []ChatMessage) (string, error
 			case string:
 				formattedMessages[i].Content = fmt.Sprintf("[%s]: %s", msg.Username, content)
 			case []ContentItem:
-				// For multimodal content, add username to text items
+				// For multimodal content, add username to the first text item
 				newContent := make([]ContentItem, len(content))
+				labeled := false
 				for j, item := range content {
 					newContent[j] = item
-					if item.Type == "text" {
+					if item.Type == "text" && !labeled {
 						newContent[j].Text = fmt.Sprintf("[%s]: %s", msg.Username, item.Text)
+						labeled = true
 					}
 				}
 				formattedMessages[i].Content = newContent
@@ -105,23 +188,97 @@ func (g *GrokClient) CreateChatCompletion(messages []ChatMessage) (string, error
 		}
 	}
 
+	model := opts.Model
+	if model == "" {
+		model = g.Config.Model
+	}
+	temperature := g.Config.Temperature
+	if opts.Temperature != nil {
+		temperature = *opts.Temperature
+	}
+	reasoningEffort := opts.ReasoningEffort
+	if reasoningEffort == "" {
+		reasoningEffort = g.Config.ReasoningEffort
+	}
+
 	request := ChatCompletionRequest{
-		Model:       g.Config.Model,
-		Messages:    formattedMessages,
-		Temperature: g.Config.Temperature,
-		MaxTokens:   g.Config.MaxTokens,
-		Stream:      g.Config.Stream,
+		Model:            model,
+		Messages:         formattedMessages,
+		Temperature:      &temperature,
+		MaxTokens:        g.Config.MaxTokens,
+		Stream:           g.Config.Stream,
+		ResponseFormat:   opts.ResponseFormat,
+		ReasoningEffort:  reasoningEffort,
+		SearchParameters: opts.Search,
+	}
+
+	var response ChatCompletionResponse
+	if err := g.postJSON(ctx, "/chat/completions", request, &response); err != nil {
+		return nil, err
+	}
+	g.recordUsage(response.Usage)
+
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned from XAI API")
 	}
 
+	completion := &Completion{
+		ReasoningContent: strings.TrimSpace(response.Choices[0].Message.ReasoningContent),
+		Citations:        response.Citations,
+		Usage:            response.Usage,
+	}
+
+	// Extract content from response, handling both string and multimodal content
+	content := response.Choices[0].Message.Content
+	switch content := content.(type) {
+	case string:
+		completion.Content = content
+	case []ContentItem:
+		// For multimodal responses, extract text content
+		var textContent strings.Builder
+		for _, item := range content {
+			if item.Type == "text" {
+				textContent.WriteString(item.Text)
+			}
+		}
+		completion.Content = textContent.String()
+	default:
+		return nil, fmt.Errorf("unexpected content type in response")
+	}
+	return completion, nil
+}
+
+// recordUsage adds the usage of one chat completion to the client's running totals
+func (g *GrokClient) recordUsage(usage Usage) {
+	g.usageMu.Lock()
+	defer g.usageMu.Unlock()
+
+	g.usage.Requests++
+	g.usage.PromptTokens += usage.PromptTokens
+	g.usage.CompletionTokens += usage.CompletionTokens
+	g.usage.ReasoningTokens += usage.CompletionTokensDetails.ReasoningTokens
+	g.usage.TotalTokens += usage.TotalTokens
+	g.usage.SourcesUsed += usage.NumSourcesUsed
+}
+
+// Usage returns the token usage accumulated since the client was created
+func (g *GrokClient) Usage() UsageTotals {
+	g.usageMu.Lock()
+	defer g.usageMu.Unlock()
+	return g.usage
+}
+
+// postJSON sends request as JSON to the given API path and decodes the JSON response into response
+func (g *GrokClient) postJSON(ctx context.Context, path string, request any, response any) error {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := g.Config.BaseURL + "/chat/completions"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	url := g.Config.BaseURL + path
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -129,49 +286,27 @@ func (g *GrokClient) CreateChatCompletion(messages []ChatMessage) (string, error
 
 	resp, err := g.Client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		var xaiErr XAIError
 		if err := json.Unmarshal(body, &xaiErr); err == nil && xaiErr.Error.Message != "" {
-			return "", fmt.Errorf("XAI API error: %s (code: %s)", xaiErr.Error.Message, xaiErr.Error.Code)
+			return fmt.Errorf("XAI API error: %s (code: %s)", xaiErr.Error.Message, xaiErr.Error.Code)
 		}
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var response ChatCompletionResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from XAI API")
-	}
-
-	// Extract content from response, handling both string and multimodal content
-	content := response.Choices[0].Message.Content
-	switch content := content.(type) {
-	case string:
-		return content, nil
-	case []ContentItem:
-		// For multimodal responses, extract text content
-		var textContent strings.Builder
-		for _, item := range content {
-			if item.Type == "text" {
-				textContent.WriteString(item.Text)
-			}
-		}
-		return textContent.String(), nil
-	default:
-		return "", fmt.Errorf("unexpected content type in response")
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	return nil
 }
 
 // CompleteText is a convenience method for simple text completion
@@ -213,6 +348,24 @@ func CreateTextMessage(role, content, username string) ChatMessage {
 	}
 }
 
+// messageText returns the text parts of a message's content, ignoring images
+func messageText(message ChatMessage) string {
+	switch content := message.Content.(type) {
+	case string:
+		return content
+	case []ContentItem:
+		var parts []string
+		for _, item := range content {
+			if item.Type == "text" && item.Text != "" {
+				parts = append(parts, item.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return ""
+	}
+}
+
 // CreateMultimodalMessage creates a ChatMessage with both text and image content
 func CreateMultimodalMessage(role, textContent string, imageURLs []string, username string) ChatMessage {
 	if len(imageURLs) == 0 {
@@ -248,6 +401,76 @@ func CreateMultimodalMessage(role, textContent string, imageURLs []string, usern
 	}
 }
 
+// CreateMessageWithFiles creates a ChatMessage with text, labeled file contents and images.
+// Each file text becomes its own text content item after the message text.
+func CreateMessageWithFiles(role, textContent string, fileTexts []string, imageURLs []string, username string) ChatMessage {
+	if len(fileTexts) == 0 {
+		return CreateMultimodalMessage(role, textContent, imageURLs, username)
+	}
+
+	var contentItems []ContentItem
+	if textContent != "" {
+		contentItems = append(contentItems, ContentItem{
+			Type: "text",
+			Text: textContent,
+		})
+	}
+	for _, fileText := range fileTexts {
+		contentItems = append(contentItems, ContentItem{
+			Type: "text",
+			Text: fileText,
+		})
+	}
+
+	// Reuse the image handling of CreateMultimodalMessage
+	if len(imageURLs) > 0 {
+		images := CreateImageOnlyMessage(role, imageURLs, username)
+		contentItems = append(contentItems, images.Content.([]ContentItem)...)
+	}
+
+	return ChatMessage{
+		Role:     role,
+		Content:  contentItems,
+		Username: username,
+	}
+}
+
+// AddImagesToMessage returns a copy of message with imageURLs appended as image content,
+// skipping URLs the message already contains
+func AddImagesToMessage(message ChatMessage, imageURLs []string) ChatMessage {
+	var contentItems []ContentItem
+	switch content := message.Content.(type) {
+	case string:
+		if content != "" {
+			contentItems = append(contentItems, ContentItem{Type: "text", Text: content})
+		}
+	case []ContentItem:
+		contentItems = append(contentItems, content...)
+	}
+
+	existing := make(map[string]bool)
+	for _, item := range contentItems {
+		if item.ImageURL != nil {
+			existing[item.ImageURL.URL] = true
+		}
+	}
+
+	var newURLs []string
+	for _, imgURL := range imageURLs {
+		if !existing[imgURL] {
+			newURLs = append(newURLs, imgURL)
+			existing[imgURL] = true
+		}
+	}
+	if len(newURLs) == 0 {
+		return message
+	}
+
+	images := CreateImageOnlyMessage(message.Role, newURLs, message.Username)
+	message.Content = append(contentItems, images.Content.([]ContentItem)...)
+	return message
+}
+
 // CreateImageOnlyMessage creates a ChatMessage with only image content
 func CreateImageOnlyMessage(role string, imageURLs []string, username string) ChatMessage {
 	return CreateMultimodalMessage(role, "", imageURLs, username)
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+)
+
+// EmbeddingRequest represents the request payload for the embeddings endpoint
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents the response from the embeddings endpoint
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// CreateEmbeddings returns one embedding per input from the OpenAI-compatible /embeddings endpoint,
+// using grok.embedding_model
+func (g *GrokClient) CreateEmbeddings(ctx context.Context, inputs []string) ([][]float32, error) {
+	if g.Config.EmbeddingModel == "" {
+		return nil, fmt.Errorf("no embedding model configured")
+	}
+
+	request := EmbeddingRequest{
+		Model: g.Config.EmbeddingModel,
+		Input: inputs,
+	}
+
+	var response EmbeddingResponse
+	if err := g.postJSON(ctx, "/embeddings", request, &response); err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float32, len(inputs))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(inputs) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	for i, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return embeddings, nil
+}
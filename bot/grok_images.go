@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+// ImageGenerationRequest represents the request payload for the images endpoint
+type ImageGenerationRequest struct {
+	Model          string `json:"model"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+}
+
+// ImageGenerationResponse represents the response from the images endpoint
+type ImageGenerationResponse struct {
+	Created int64 `json:"created"`
+	Data    []struct {
+		B64JSON       string `json:"b64_json,omitempty"`
+		URL           string `json:"url,omitempty"`
+		RevisedPrompt string `json:"revised_prompt,omitempty"`
+	} `json:"data"`
+}
+
+// GeneratedImage is a decoded image returned by GenerateImage
+type GeneratedImage struct {
+	Data          []byte
+	ContentType   string
+	RevisedPrompt string
+}
+
+// GenerateImage requests n images for prompt from the OpenAI-compatible /images/generations endpoint
+func (g *GrokClient) GenerateImage(ctx context.Context, prompt string, n int) ([]GeneratedImage, error) {
+	request := ImageGenerationRequest{
+		Model:          g.Config.ImageModel,
+		Prompt:         prompt,
+		N:              n,
+		ResponseFormat: "b64_json",
+	}
+
+	var response ImageGenerationResponse
+	if err := g.postJSON(ctx, "/images/generations", request, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("no images returned from XAI API")
+	}
+
+	images := make([]GeneratedImage, 0, len(response.Data))
+	for _, item := range response.Data {
+		var data []byte
+		switch {
+		case item.B64JSON != "":
+			decoded, err := base64.StdEncoding.DecodeString(item.B64JSON)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode generated image: %w", err)
+			}
+			data = decoded
+		case item.URL != "":
+			downloaded, _, err := downloadImage(item.URL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download generated image: %w", err)
+			}
+			data = downloaded
+		default:
+			continue
+		}
+
+		images = append(images, GeneratedImage{
+			Data:          data,
+			ContentType:   http.DetectContentType(data),
+			RevisedPrompt: item.RevisedPrompt,
+		})
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no usable images returned from XAI API")
+	}
+	return images, nil
+}
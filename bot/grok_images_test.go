@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// pngHeader is enough of a PNG for content type detection
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestGenerateImageAgainstFakeEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/generations" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+
+		var request ImageGenerationRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+		if request.Model != "test-image-model" || request.Prompt != "a red fox" || request.N != 1 || request.ResponseFormat != "b64_json" {
+			t.Errorf("unexpected request %+v", request)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"created": 1,
+			"data": []map[string]string{{
+				"b64_json":       base64.StdEncoding.EncodeToString(pngHeader),
+				"revised_prompt": "a red fox in the snow",
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewGrokClient(&GrokConfig{APIKey: "test-key", BaseURL: server.URL, ImageModel: "test-image-model"})
+	images, err := client.GenerateImage(context.Background(), "a red fox", 1)
+	if err != nil {
+		t.Fatalf("GenerateImage failed: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(images))
+	}
+	if !bytes.Equal(images[0].Data, pngHeader) || images[0].ContentType != "image/png" || images[0].RevisedPrompt != "a red fox in the snow" {
+		t.Errorf("unexpected image %+v", images[0])
+	}
+}
+
+func TestGenerateImageReportsAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"content policy"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewGrokClient(&GrokConfig{APIKey: "test-key", BaseURL: server.URL, ImageModel: "test-image-model"})
+	if _, err := client.GenerateImage(context.Background(), "a red fox", 1); err == nil {
+		t.Fatal("expected an error for a failed request")
+	}
+}
+
+func TestUserQuotaRefund(t *testing.T) {
+	quota := NewUserQuota(1, time.Hour)
+	if ok, _ := quota.Allow("user"); !ok {
+		t.Fatal("first use should be allowed")
+	}
+	quota.Refund("user")
+	if ok, _ := quota.Allow("user"); !ok {
+		t.Fatal("refunded use should be allowed again")
+	}
+	if ok, _ := quota.Allow("user"); ok {
+		t.Fatal("use over the limit should be refused")
+	}
+}
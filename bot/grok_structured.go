@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CompleteWithSchema asks the model to answer userMessage with JSON matching schema and decodes
+// the answer into out. If the answer is malformed or fails validation, the model is shown the
+// error and asked once to repair it. Any response format in opts is replaced by the schema.
+func (g *GrokClient) CompleteWithSchema(ctx context.Context, systemMessage, userMessage, name string, schema map[string]any, out any, opts CompletionOptions) error {
+	messages := []ChatMessage{
+		{Role: "system", Content: systemMessage},
+		{Role: "user", Content: userMessage},
+	}
+	opts.ResponseFormat = &ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &JSONSchemaFormat{
+			Name:   name,
+			Schema: schema,
+			Strict: true,
+		},
+	}
+
+	response, err := g.CreateChatCompletionWithOptions(ctx, messages, opts)
+	if err != nil {
+		return err
+	}
+
+	decodeErr := decodeStructured(response, schema, out)
+	if decodeErr == nil {
+		return nil
+	}
+
+	// One repair attempt: show the model its answer and what was wrong with it
+	messages = append(messages,
+		ChatMessage{Role: "assistant", Content: response},
+		ChatMessage{Role: "user", Content: fmt.Sprintf("That response was not valid: %v. Reply again with only JSON that matches the schema.", decodeErr)},
+	)
+	response, err = g.CreateChatCompletionWithOptions(ctx, messages, opts)
+	if err != nil {
+		return err
+	}
+	if err := decodeStructured(response, schema, out); err != nil {
+		return fmt.Errorf("invalid structured response after repair: %w", err)
+	}
+	return nil
+}
+
+// CompleteStruct is CompleteWithSchema with the schema derived from out, which must be a pointer to a struct
+func (g *GrokClient) CompleteStruct(ctx context.Context, systemMessage, userMessage string, out any, opts CompletionOptions) error {
+	t := reflect.TypeOf(out)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("CompleteStruct requires a pointer to a struct, got %T", out)
+	}
+	return g.CompleteWithSchema(ctx, systemMessage, userMessage, schemaName(t.Elem()), SchemaFor(out), out, opts)
+}
+
+// decodeStructured validates a JSON response against schema and decodes it into out
+func decodeStructured(response string, schema map[string]any, out any) error {
+	response = stripCodeFence(response)
+
+	var value any
+	if err := json.Unmarshal([]byte(response), &value); err != nil {
+		return fmt.Errorf("malformed JSON: %w", err)
+	}
+	if err := validateAgainstSchema(value, schema, ""); err != nil {
+		return fmt.Errorf("schema validation failed: %w", err)
+	}
+	if err := json.Unmarshal([]byte(response), out); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return nil
+}
+
+// stripCodeFence removes a surrounding markdown code fence some models add around JSON
+func stripCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "```") {
+		return response
+	}
+	response = strings.TrimPrefix(response, "```")
+	if newline := strings.IndexByte(response, '\n'); newline >= 0 {
+		response = response[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), "```"))
+}
+
+// schemaName turns a Go type name into a schema name accepted by the API
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return "response"
+	}
+	return strings.ToLower(name)
+}
@@ -1,6 +1,9 @@
 package bot
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+)
 
 // ChatHistory manages per-channel rolling histories of ChatMessage
 // It stores the most recent maxMessages messages for each channel.
@@ -68,3 +71,198 @@ func (h *ChatHistory) GetMax() int {
 	defer h.mu.Unlock()
 	return h.maxMessages
 }
+
+// GetBefore returns a COPY of the history for a channel up to, but not including,
+// the entry with the given message ID. If no entry matches, the full history is returned.
+func (h *ChatHistory) GetBefore(channelID, messageID string) []ChatMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	src := h.channelToMessages[channelID]
+	for i, msg := range src {
+		if messageID != "" && msg.MessageID == messageID {
+			src = src[:i]
+			break
+		}
+	}
+	out := make([]ChatMessage, len(src))
+	copy(out, src)
+	return out
+}
+
+// Replace swaps the entry with the given message ID for message, keeping its position.
+// It returns false if no entry with that ID is in the channel history.
+func (h *ChatHistory) Replace(channelID, messageID string, message ChatMessage) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if messageID == "" {
+		return false
+	}
+	messages := h.channelToMessages[channelID]
+	for i, msg := range messages {
+		if msg.MessageID == messageID {
+			messages[i] = message
+			return true
+		}
+	}
+	return false
+}
+
+// Update applies fn to the entry with the given message ID, keeping its position.
+// It returns false if no entry with that ID is in the channel history.
+func (h *ChatHistory) Update(channelID, messageID string, fn func(ChatMessage) ChatMessage) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if messageID == "" {
+		return false
+	}
+	messages := h.channelToMessages[channelID]
+	for i, msg := range messages {
+		if msg.MessageID == messageID {
+			messages[i] = fn(msg)
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the channel history has an entry with the given message ID
+func (h *ChatHistory) Contains(channelID, messageID string) bool {
+	return h.Update(channelID, messageID, func(msg ChatMessage) ChatMessage { return msg })
+}
+
+// LastMessageID returns the newest Discord message ID recorded in a channel's history,
+// or "" if none of its entries carry an ID.
+func (h *ChatHistory) LastMessageID(channelID string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last := ""
+	for _, msg := range h.channelToMessages[channelID] {
+		if msg.MessageID != "" && (last == "" || snowflakeLess(last, msg.MessageID)) {
+			last = msg.MessageID
+		}
+	}
+	return last
+}
+
+// Merge inserts older messages (oldest first) into a channel's history in message ID order,
+// skipping entries already present. It is used by backfill, which may finish after live
+// messages have been appended.
+func (h *ChatHistory) Merge(channelID string, older []ChatMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	existing := h.channelToMessages[channelID]
+	seen := make(map[string]bool, len(existing))
+	for _, msg := range existing {
+		if msg.MessageID != "" {
+			seen[msg.MessageID] = true
+		}
+	}
+
+	var batch []ChatMessage
+	for _, msg := range older {
+		if msg.MessageID != "" && seen[msg.MessageID] {
+			continue
+		}
+		batch = append(batch, msg)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	// Insert the batch after the last existing entry that predates it
+	firstID := batch[0].MessageID
+	insertAt := 0
+	for i, msg := range existing {
+		if msg.MessageID == "" || firstID == "" || snowflakeLess(msg.MessageID, firstID) {
+			insertAt = i + 1
+			continue
+		}
+		break
+	}
+
+	merged := make([]ChatMessage, 0, len(existing)+len(batch))
+	merged = append(merged, existing[:insertAt]...)
+	merged = append(merged, batch...)
+	merged = append(merged, existing[insertAt:]...)
+	if len(merged) > h.maxMessages {
+		merged = merged[len(merged)-h.maxMessages:]
+	}
+	h.channelToMessages[channelID] = merged
+}
+
+// storedMessage is the on-disk form of a ChatMessage
+type storedMessage struct {
+	Role      string        `json:"role"`
+	Text      string        `json:"text,omitempty"`
+	Items     []ContentItem `json:"items,omitempty"`
+	Username  string        `json:"username,omitempty"`
+	MessageID string        `json:"message_id,omitempty"`
+}
+
+// Save writes all channel histories to path as JSON
+func (h *ChatHistory) Save(path string) error {
+	h.mu.Lock()
+	stored := make(map[string][]storedMessage, len(h.channelToMessages))
+	for cid, msgs := range h.channelToMessages {
+		out := make([]storedMessage, 0, len(msgs))
+		for _, msg := range msgs {
+			sm := storedMessage{Role: msg.Role, Username: msg.Username, MessageID: msg.MessageID}
+			switch content := msg.Content.(type) {
+			case string:
+				sm.Text = content
+			case []ContentItem:
+				sm.Items = content
+			}
+			out = append(out, sm)
+		}
+		stored[cid] = out
+	}
+	h.mu.Unlock()
+
+	return writeJSONFile(path, stored)
+}
+
+// Load replaces the in-memory histories with those saved at path.
+// A missing file leaves the history empty.
+func (h *ChatHistory) Load(path string) error {
+	var stored map[string][]storedMessage
+	found, err := readJSONFile(path, &stored)
+	if err != nil {
+		return fmt.Errorf("failed to load chat history: %w", err)
+	}
+	if !found {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for cid, msgs := range stored {
+		out := make([]ChatMessage, 0, len(msgs))
+		for _, sm := range msgs {
+			msg := ChatMessage{Role: sm.Role, Content: sm.Text, Username: sm.Username, MessageID: sm.MessageID}
+			if len(sm.Items) > 0 {
+				msg.Content = sm.Items
+			}
+			out = append(out, msg)
+		}
+		if len(out) > h.maxMessages {
+			out = out[len(out)-h.maxMessages:]
+		}
+		h.channelToMessages[cid] = out
+	}
+	return nil
+}
+
+// snowflakeLess reports whether Discord snowflake a is older than b
+func snowflakeLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
@@ -0,0 +1,268 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imageRefPrefix marks image URLs in chat history that point into the ImageCache
+const imageRefPrefix = "image-cache:"
+
+// imageOmittedPlaceholder replaces images that are too old to send or no longer cached
+const imageOmittedPlaceholder = "[image omitted]"
+
+// cachedImage is an image held in memory by the ImageCache
+type cachedImage struct {
+	data        []byte
+	contentType string
+	lastUsed    time.Time
+}
+
+// ImageCache stores downloaded images by content hash, in memory and on disk, so chat history
+// only needs to hold short references. Both tiers are bounded by size, and disk entries
+// expire after a TTL since their last use.
+type ImageCache struct {
+	mu         sync.Mutex
+	dir        string
+	maxMemory  int64
+	maxDisk    int64
+	ttl        time.Duration
+	memory     map[string]*cachedImage
+	memoryUsed int64
+}
+
+// NewImageCache constructs an ImageCache persisting images under dir
+func NewImageCache(dir string, maxMemory, maxDisk int64, ttl time.Duration) *ImageCache {
+	return &ImageCache{
+		dir:       dir,
+		maxMemory: maxMemory,
+		maxDisk:   maxDisk,
+		ttl:       ttl,
+		memory:    make(map[string]*cachedImage),
+	}
+}
+
+// Put stores an image and returns a reference to it for use as an image URL in chat history
+func (c *ImageCache) Put(data []byte, contentType string) string {
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.storeInMemoryLocked(key, data, contentType)
+
+	path := filepath.Join(c.dir, key)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+	} else if err := c.writeToDisk(path, data); err != nil {
+		log.Printf("Error writing image %s to cache: %v", key, err)
+	}
+
+	return imageRefPrefix + key
+}
+
+// Get returns the image data and content type for a reference created by Put
+func (c *ImageCache) Get(ref string) ([]byte, string, bool) {
+	key := strings.TrimPrefix(ref, imageRefPrefix)
+	if key == ref || !isHexKey(key) {
+		return nil, "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := filepath.Join(c.dir, key)
+	if img, ok := c.memory[key]; ok {
+		img.lastUsed = time.Now()
+		// Keep the disk copy from expiring while the image is still in use
+		os.Chtimes(path, img.lastUsed, img.lastUsed)
+		return img.data, img.contentType, true
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", false
+	}
+	if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		os.Remove(path)
+		return nil, "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Error reading cached image %s: %v", key, err)
+		return nil, "", false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	contentType := http.DetectContentType(data)
+	c.storeInMemoryLocked(key, data, contentType)
+	return data, contentType, true
+}
+
+// Prune removes expired images from disk and deletes the least recently used
+// images until the disk cache fits its size limit
+func (c *ImageCache) Prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading image cache directory: %v", err)
+		}
+		return
+	}
+
+	type diskImage struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var images []diskImage
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !isHexKey(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, entry.Name())
+		if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+			os.Remove(path)
+			continue
+		}
+		images = append(images, diskImage{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	// Oldest first
+	sort.Slice(images, func(i, j int) bool { return images[i].modTime.Before(images[j].modTime) })
+	for _, img := range images {
+		if total <= c.maxDisk {
+			break
+		}
+		if err := os.Remove(img.path); err == nil {
+			total -= img.size
+		}
+	}
+}
+
+// storeInMemoryLocked adds an image to the memory tier, evicting least recently used
+// images to stay within the memory limit. Callers must hold c.mu.
+func (c *ImageCache) storeInMemoryLocked(key string, data []byte, contentType string) {
+	if img, ok := c.memory[key]; ok {
+		img.lastUsed = time.Now()
+		return
+	}
+	if int64(len(data)) > c.maxMemory {
+		return
+	}
+
+	for c.memoryUsed+int64(len(data)) > c.maxMemory {
+		oldestKey := ""
+		var oldest time.Time
+		for k, img := range c.memory {
+			if oldestKey == "" || img.lastUsed.Before(oldest) {
+				oldestKey, oldest = k, img.lastUsed
+			}
+		}
+		c.memoryUsed -= int64(len(c.memory[oldestKey].data))
+		delete(c.memory, oldestKey)
+	}
+
+	c.memory[key] = &cachedImage{data: data, contentType: contentType, lastUsed: time.Now()}
+	c.memoryUsed += int64(len(data))
+}
+
+// writeToDisk atomically writes an image file into the cache directory
+func (c *ImageCache) writeToDisk(path string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// isHexKey reports whether name looks like a SHA-256 hex digest
+func isHexKey(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+// resolveImages prepares messages for a request: cached image references in the newest
+// bot.max_history_images history images are encoded as data URLs, and older or missing images
+// are replaced with a text placeholder. Images on a final user message, the one being
+// answered, are always sent and don't count toward the limit.
+func resolveImages(messages []ChatMessage) []ChatMessage {
+	remaining := config.Bot.MaxHistoryImages
+	out := make([]ChatMessage, len(messages))
+
+	// Walk newest to oldest so the most recent images are kept
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		items, ok := msg.Content.([]ContentItem)
+		if !ok {
+			out[i] = msg
+			continue
+		}
+		current := i == len(messages)-1 && msg.Role == "user"
+
+		resolved := make([]ContentItem, 0, len(items))
+		for _, item := range items {
+			if item.Type != "image_url" || item.ImageURL == nil {
+				resolved = append(resolved, item)
+				continue
+			}
+
+			url := item.ImageURL.URL
+			if remaining <= 0 && !current {
+				resolved = append(resolved, ContentItem{Type: "text", Text: imageOmittedPlaceholder})
+				continue
+			}
+			if strings.HasPrefix(url, imageRefPrefix) {
+				data, contentType, found := imageCache.Get(url)
+				if !found {
+					resolved = append(resolved, ContentItem{Type: "text", Text: imageOmittedPlaceholder})
+					continue
+				}
+				url = imageToDataURL(data, contentType)
+			}
+			if !current {
+				remaining--
+			}
+
+			resolved = append(resolved, ContentItem{
+				Type: "image_url",
+				ImageURL: &struct {
+					URL string `json:"url"`
+				}{URL: url},
+			})
+		}
+
+		msg.Content = resolved
+		out[i] = msg
+	}
+
+	return out
+}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoding; image.Decode returns the first frame
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoding
+)
+
+// jpegQualities are tried in order until the encoded image fits the byte budget
+var jpegQualities = []int{90, 80, 70, 60, 50}
+
+// maxImagePixels bounds the size of an image that will be decoded. A small file can declare
+// enormous dimensions, and decoding it would allocate memory for every pixel.
+const maxImagePixels = 50_000_000
+
+// passthroughFormats are formats the Grok API accepts as uploaded
+var passthroughFormats = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"webp": "image/webp",
+}
+
+// normalizeImage prepares image data for the Grok API. Images already in a supported
+// format that fit bot.max_image_dimension and bot.max_image_bytes are returned unchanged.
+// Anything else (including animated GIFs, which are reduced to their first frame) is
+// downscaled and re-encoded as PNG when it has transparency or JPEG otherwise, shrinking
+// further until it fits the byte budget.
+func normalizeImage(data []byte) ([]byte, string, error) {
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if header.Width*header.Height > maxImagePixels {
+		return nil, "", fmt.Errorf("image is too large to process (%dx%d)", header.Width, header.Height)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	maxDimension := config.Bot.MaxImageDimension
+	maxBytes := config.Bot.MaxImageBytes
+
+	bounds := img.Bounds()
+	contentType, supported := passthroughFormats[format]
+	if supported && bounds.Dx() <= maxDimension && bounds.Dy() <= maxDimension && len(data) <= maxBytes {
+		return data, contentType, nil
+	}
+
+	scaled := downscale(img, maxDimension)
+	for attempt := 0; attempt < 4; attempt++ {
+		encoded, contentType, err := encodeWithinBudget(scaled, maxBytes)
+		if err != nil {
+			return nil, "", err
+		}
+		if encoded != nil {
+			return encoded, contentType, nil
+		}
+
+		// Still too large at the lowest quality; shrink and try again
+		b := scaled.Bounds()
+		scaled = downscale(scaled, max(b.Dx(), b.Dy())*3/4)
+	}
+
+	return nil, "", fmt.Errorf("image could not be reduced below %d bytes", maxBytes)
+}
+
+// encodeWithinBudget encodes img as PNG (if it has transparency) or JPEG and returns nil data
+// if no encoding fits within maxBytes
+func encodeWithinBudget(img image.Image, maxBytes int) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		if buf.Len() <= maxBytes {
+			return buf.Bytes(), "image/png", nil
+		}
+		// Too large as PNG; fall back to JPEG on a white background
+		img = flatten(img)
+	}
+
+	for _, quality := range jpegQualities {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+		if buf.Len() <= maxBytes {
+			return buf.Bytes(), "image/jpeg", nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// downscale resizes img so its longest side is at most maxDimension, preserving aspect ratio.
+// The result is always an *image.RGBA so later encoding sees a consistent type.
+func downscale(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if longest := max(width, height); longest > maxDimension {
+		width = max(1, width*maxDimension/longest)
+		height = max(1, height*maxDimension/longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// flatten composites img onto a white background, dropping transparency
+func flatten(img image.Image) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
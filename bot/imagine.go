@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// drawIntentPrefixes are message openings that ask the bot for an image instead of a chat reply
+var drawIntentPrefixes = []string{
+	"draw ",
+	"imagine an image of ",
+	"generate an image of ",
+	"make an image of ",
+}
+
+// maxImageCaptionLength caps how much of the prompt is shown above a generated image
+const maxImageCaptionLength = 1000
+
+// imagineCommand defines the /imagine slash command
+func imagineCommand() slashCommand {
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:        "imagine",
+			Description: "Generate an image from a prompt",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "prompt",
+					Description: "What the image should show",
+					Required:    true,
+				},
+			},
+		},
+		handler: handleImagineCommand,
+	}
+}
+
+// handleImagineCommand generates an image for /imagine and attaches it to the interaction response
+func handleImagineCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	prompt := strings.TrimSpace(interaction.ApplicationCommandData().Options[0].StringValue())
+	user := interactionUser(interaction)
+
+	if ok, wait := imageQuota.Allow(user.ID); !ok {
+		respondEphemeral(discord, interaction, quotaExceededMessage(wait))
+		return
+	}
+
+	if err := deferResponse(discord, interaction); err != nil {
+		log.Printf("Error deferring /imagine response: %v", err)
+		return
+	}
+
+	_, err := requestQueue.Submit(interaction.ChannelID, func() {
+		images, err := grokClient.GenerateImage(rootCtx, prompt, 1)
+		if err != nil {
+			log.Printf("Error generating image: %v", err)
+			imageQuota.Refund(user.ID)
+			editResponse(discord, interaction, "Sorry, I couldn't generate that image. Please try again.")
+			return
+		}
+
+		content := imageCaption(prompt)
+		_, err = discord.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+			Files:   generatedImageFiles(images),
+		})
+		if err != nil {
+			log.Printf("Error sending generated image: %v", err)
+		}
+	})
+	if err != nil {
+		imageQuota.Refund(user.ID)
+		editResponse(discord, interaction, "I'm swamped in this channel right now, please try again in a moment.")
+	}
+}
+
+// parseDrawIntent returns the image prompt if a message addressed to the bot asks it to draw something
+func parseDrawIntent(content string) (string, bool) {
+	lower := strings.ToLower(content)
+	for _, prefix := range drawIntentPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			prompt := strings.TrimSpace(content[len(prefix):])
+			return prompt, prompt != ""
+		}
+	}
+	return "", false
+}
+
+// respondWithImage generates an image for a "@grok draw ..." message and posts it to the channel.
+// It runs on the channel's request queue.
+func respondWithImage(discord *discordgo.Session, message *discordgo.MessageCreate, prompt string) {
+	channelID := message.ChannelID
+
+	if ok, wait := imageQuota.Allow(message.Author.ID); !ok {
+		discord.ChannelMessageSend(channelID, quotaExceededMessage(wait))
+		return
+	}
+
+	stopTyping := keepTyping(rootCtx, discord, channelID)
+	images, err := grokClient.GenerateImage(rootCtx, prompt, 1)
+	stopTyping()
+	if err != nil {
+		log.Printf("Error generating image: %v", err)
+		imageQuota.Refund(message.Author.ID)
+		discord.ChannelMessageSend(channelID, "Sorry, I couldn't generate that image. Please try again.")
+		return
+	}
+
+	for _, file := range generatedImageFiles(images) {
+		if _, err := discord.ChannelFileSend(channelID, file.Name, file.Reader); err != nil {
+			log.Printf("Error sending generated image: %v", err)
+		}
+	}
+}
+
+// imageCaption formats the prompt shown above a generated image, shortened to fit in a message
+func imageCaption(prompt string) string {
+	if len(prompt) > maxImageCaptionLength {
+		prompt = strings.ToValidUTF8(prompt[:maxImageCaptionLength], "") + "..."
+	}
+	return fmt.Sprintf("**%s**", prompt)
+}
+
+// generatedImageFiles wraps generated images as Discord file uploads
+func generatedImageFiles(images []GeneratedImage) []*discordgo.File {
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	files := make([]*discordgo.File, 0, len(images))
+	for i, img := range images {
+		files = append(files, &discordgo.File{
+			Name:        fmt.Sprintf("grok_image_%s_%d.%s", timestamp, i+1, imageExtension(img.ContentType)),
+			ContentType: img.ContentType,
+			Reader:      bytes.NewReader(img.Data),
+		})
+	}
+	return files
+}
+
+// imageExtensions maps detected image content types to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+	"image/gif":  "gif",
+}
+
+// imageExtension picks the file extension for an image of the given content type
+func imageExtension(contentType string) string {
+	if ext, ok := imageExtensions[contentType]; ok {
+		return ext
+	}
+	if subtype, ok := strings.CutPrefix(contentType, "image/"); ok && subtype != "" {
+		return subtype
+	}
+	return "jpg"
+}
+
+// quotaExceededMessage tells a user when they may generate images again
+func quotaExceededMessage(wait time.Duration) string {
+	return fmt.Sprintf("You've hit your image generation limit. Try again in %s.", wait.Round(time.Second))
+}
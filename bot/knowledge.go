@@ -0,0 +1,322 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// knowledgeDir is the directory inside bot.data_dir holding ingested knowledge bases
+const knowledgeDir = "knowledge"
+
+// knowledgeEmbeddingBatch is how many chunks are embedded per /embeddings request during ingestion
+const knowledgeEmbeddingBatch = 64
+
+// knowledgeNamePattern restricts knowledge base names to safe file names
+var knowledgeNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// markdownHeadingPattern matches a Markdown ATX heading line
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// knowledgeExtensions are the file types ingested into knowledge bases
+var knowledgeExtensions = map[string]bool{".md": true, ".markdown": true, ".txt": true}
+
+// KnowledgeChunk is a section of an ingested document
+type KnowledgeChunk struct {
+	File      string    `json:"file"`              // Path relative to the ingested directory
+	Heading   string    `json:"heading,omitempty"` // Markdown heading path, e.g. "Deploys › Rollback"
+	Text      string    `json:"text"`
+	Embedding []float32 `json:"embedding,omitempty"`
+
+	tokens []string // Lexical search terms, derived from Heading and Text
+}
+
+// Citation names the chunk's source for answers
+func (c KnowledgeChunk) Citation() string {
+	if c.Heading == "" {
+		return c.File
+	}
+	return c.File + " › " + c.Heading
+}
+
+// KnowledgeBase is a set of document chunks ingested with `grok-bot ingest`
+type KnowledgeBase struct {
+	Name       string           `json:"name"`
+	IngestedAt time.Time        `json:"ingested_at"`
+	Chunks     []KnowledgeChunk `json:"chunks"`
+}
+
+// Search returns up to k chunks most relevant to query, best first. Chunks ranked by embedding
+// must be at least bot.knowledge.min_similarity similar to the query.
+func (kb *KnowledgeBase) Search(query string, queryEmbedding []float32, k int) []KnowledgeChunk {
+	tokens := make([][]string, len(kb.Chunks))
+	embeddings := make([][]float32, len(kb.Chunks))
+	for i, chunk := range kb.Chunks {
+		tokens[i] = chunk.tokens
+		embeddings[i] = chunk.Embedding
+	}
+
+	var out []KnowledgeChunk
+	for _, i := range topScores(rankDocuments(query, queryEmbedding, tokens, embeddings, config.Bot.Knowledge.MinSimilarity), k, 0) {
+		out = append(out, kb.Chunks[i])
+	}
+	return out
+}
+
+// knowledgeBasePath returns where the knowledge base called name is stored
+func knowledgeBasePath(name string) string {
+	return dataPath(filepath.Join(knowledgeDir, name+".json"))
+}
+
+// loadedKnowledgeBase is a knowledge base read from disk with the modification time it had then
+type loadedKnowledgeBase struct {
+	kb      *KnowledgeBase
+	modTime time.Time
+}
+
+// knowledgeBases caches loaded knowledge bases by name
+var (
+	knowledgeBasesMu sync.Mutex
+	knowledgeBases   = make(map[string]loadedKnowledgeBase)
+)
+
+// loadKnowledgeBase returns the knowledge base called name, re-reading it when it was ingested
+// again since it was last loaded
+func loadKnowledgeBase(name string) (*KnowledgeBase, error) {
+	path := knowledgeBasePath(name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge base %q has not been ingested: %w", name, err)
+	}
+
+	knowledgeBasesMu.Lock()
+	defer knowledgeBasesMu.Unlock()
+
+	if loaded, ok := knowledgeBases[name]; ok && loaded.modTime.Equal(info.ModTime()) {
+		return loaded.kb, nil
+	}
+
+	var kb KnowledgeBase
+	if _, err := readJSONFile(path, &kb); err != nil {
+		return nil, err
+	}
+	for i := range kb.Chunks {
+		kb.Chunks[i].tokens = tokenize(kb.Chunks[i].Heading + " " + kb.Chunks[i].Text)
+	}
+	knowledgeBases[name] = loadedKnowledgeBase{kb: &kb, modTime: info.ModTime()}
+	return &kb, nil
+}
+
+// consultKnowledgeBase finds chunks of the guild's knowledge base relevant to query and formats
+// them, with their file names, as context for the model. It returns "" when the guild has no
+// knowledge base or nothing relevant was found.
+func consultKnowledgeBase(ctx context.Context, guildID, query string) string {
+	name := config.Bot.Knowledge.Guilds[guildID]
+	if name == "" || strings.TrimSpace(query) == "" {
+		return ""
+	}
+
+	kb, err := loadKnowledgeBase(name)
+	if err != nil {
+		log.Printf("Error loading knowledge base for guild %s: %v", guildID, err)
+		return ""
+	}
+
+	var queryEmbedding []float32
+	if config.Grok.EmbeddingModel != "" {
+		embeddings, err := grokClient.CreateEmbeddings(ctx, []string{query})
+		if err != nil {
+			log.Printf("Error embedding query, using lexical search: %v", err)
+		} else {
+			queryEmbedding = embeddings[0]
+		}
+	}
+
+	chunks := kb.Search(query, queryEmbedding, config.Bot.Knowledge.Results)
+	if len(chunks) == 0 {
+		return ""
+	}
+
+	var excerpts strings.Builder
+	excerpts.WriteString("Excerpts from the team's documentation that may answer the question. When you use one, cite its file name in square brackets, e.g. [deploy.md]:")
+	for _, chunk := range chunks {
+		fmt.Fprintf(&excerpts, "\n\n[%s]\n%s", chunk.Citation(), chunk.Text)
+	}
+	return excerpts.String()
+}
+
+// Ingest chunks the Markdown and text files under dir into the knowledge base called name,
+// embedding the chunks when grok.embedding_model is set, and replaces any earlier version of it.
+// It returns the number of files and chunks ingested.
+func Ingest(ctx context.Context, cfg *Config, name, dir string) (files int, chunks int, err error) {
+	config = cfg
+	grokClient = NewGrokClient(&config.Grok)
+
+	if !knowledgeNamePattern.MatchString(name) {
+		return 0, 0, fmt.Errorf("knowledge base name %q must be lowercase letters, digits, - and _", name)
+	}
+
+	kb := &KnowledgeBase{Name: name, IngestedAt: time.Now()}
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !knowledgeExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		text, err := decodeUTF8Text(data)
+		if err != nil {
+			log.Printf("Skipping %s: %v", path, err)
+			return nil
+		}
+
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fileChunks := chunkDocument(filepath.ToSlash(relative), text, config.Bot.Knowledge.ChunkSize)
+		kb.Chunks = append(kb.Chunks, fileChunks...)
+		files++
+		log.Printf("  - %s: %d chunks", relative, len(fileChunks))
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(kb.Chunks) == 0 {
+		return 0, 0, fmt.Errorf("no Markdown or text files with content found in %s", dir)
+	}
+
+	if config.Grok.EmbeddingModel != "" {
+		if err := embedKnowledgeChunks(ctx, kb.Chunks); err != nil {
+			return 0, 0, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+	}
+
+	if err := writeJSONFile(knowledgeBasePath(name), kb); err != nil {
+		return 0, 0, err
+	}
+	return files, len(kb.Chunks), nil
+}
+
+// embedKnowledgeChunks embeds chunks in place, in batches
+func embedKnowledgeChunks(ctx context.Context, chunks []KnowledgeChunk) error {
+	for start := 0; start < len(chunks); start += knowledgeEmbeddingBatch {
+		batch := chunks[start:min(start+knowledgeEmbeddingBatch, len(chunks))]
+
+		inputs := make([]string, len(batch))
+		for i, chunk := range batch {
+			inputs[i] = chunk.Citation() + "\n" + chunk.Text
+		}
+		embeddings, err := grokClient.CreateEmbeddings(ctx, inputs)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			batch[i].Embedding = embeddings[i]
+		}
+	}
+	return nil
+}
+
+// chunkDocument splits a document into chunks of at most maxSize bytes. Markdown is split at
+// headings first, and each chunk remembers the headings it falls under; long sections are split
+// at paragraph breaks, and paragraphs longer than maxSize at line breaks or hard limits.
+func chunkDocument(file, text string, maxSize int) []KnowledgeChunk {
+	var chunks []KnowledgeChunk
+	var headings []string
+	var section strings.Builder
+	inCodeBlock := false
+
+	flush := func() {
+		heading := strings.Join(headings, " › ")
+		for _, piece := range splitSection(section.String(), maxSize) {
+			chunks = append(chunks, KnowledgeChunk{File: file, Heading: heading, Text: piece})
+		}
+		section.Reset()
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+		}
+		if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil && !inCodeBlock {
+			flush()
+			level := len(match[1])
+			if len(headings) >= level {
+				headings = headings[:level-1]
+			}
+			headings = append(headings, match[2])
+			continue
+		}
+		section.WriteString(line)
+		section.WriteString("\n")
+	}
+	flush()
+	return chunks
+}
+
+// splitSection packs the paragraphs of a section into pieces of at most maxSize bytes
+func splitSection(section string, maxSize int) []string {
+	var pieces []string
+	var piece strings.Builder
+
+	add := func(part, separator string) {
+		if piece.Len() > 0 && piece.Len()+len(separator)+len(part) > maxSize {
+			pieces = append(pieces, strings.TrimSpace(piece.String()))
+			piece.Reset()
+		}
+		if piece.Len() > 0 {
+			piece.WriteString(separator)
+		}
+		piece.WriteString(part)
+	}
+
+	for _, paragraph := range strings.Split(section, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if len(paragraph) <= maxSize {
+			add(paragraph, "\n\n")
+			continue
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			for len(line) > maxSize {
+				cut := maxSize
+				if space := strings.LastIndex(line[:maxSize], " "); space > 0 {
+					cut = space
+				}
+				for cut > 1 && !utf8.RuneStart(line[cut]) {
+					cut--
+				}
+				add(line[:cut], "\n")
+				line = strings.TrimSpace(line[cut:])
+			}
+			add(line, "\n")
+		}
+	}
+	if strings.TrimSpace(piece.String()) != "" {
+		pieces = append(pieces, strings.TrimSpace(piece.String()))
+	}
+	return pieces
+}
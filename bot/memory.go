@@ -0,0 +1,289 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memoriesFile is the name of the persisted user memories inside bot.data_dir
+const memoriesFile = "memories.json"
+
+// maxMemoryFactLength caps the length of a single remembered fact
+const maxMemoryFactLength = 200
+
+// memoryExtractionPrompt instructs the model that picks facts worth remembering after a conversation turn
+const memoryExtractionPrompt = `You maintain a Discord bot's long-term memory about one user.
+
+You will be given what the bot already remembers about the user (numbered), the user's latest message and the bot's reply. Pick out lasting facts about the user that would help in future conversations: their name, preferences, skills, projects, and anything they explicitly ask the bot to remember. Write each as a short third-person sentence, e.g. "Prefers Python over Go".
+
+Do not save facts about other people, one-off requests, the bot itself, or anything already remembered. Do not save secrets such as passwords or tokens. List the numbers of remembered facts the user contradicted or asked the bot to forget. Most turns contain nothing worth saving; then return empty lists.`
+
+// memoryExtraction is the model's structured answer after a conversation turn
+type memoryExtraction struct {
+	Remember []string `json:"remember" description:"New lasting facts about the user, each a short sentence"`
+	Forget   []int    `json:"forget" description:"Numbers of remembered facts that are now wrong or that the user asked to forget"`
+}
+
+// MemoryFact is one thing the bot remembers about a user
+type MemoryFact struct {
+	Text  string    `json:"text"`
+	Saved time.Time `json:"saved"`
+}
+
+// MemoryStore keeps short facts about each user across channels, oldest first
+type MemoryStore struct {
+	mu       sync.Mutex
+	users    map[string][]MemoryFact // user ID -> facts
+	maxFacts int
+	path     string
+}
+
+// NewMemoryStore constructs a MemoryStore keeping up to maxFacts per user and persisting them to path
+func NewMemoryStore(maxFacts int, path string) *MemoryStore {
+	return &MemoryStore{
+		users:    make(map[string][]MemoryFact),
+		maxFacts: maxFacts,
+		path:     path,
+	}
+}
+
+// Load reads persisted memories, if any
+func (s *MemoryStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := readJSONFile(s.path, &s.users)
+	if s.users == nil {
+		s.users = make(map[string][]MemoryFact)
+	}
+	return err
+}
+
+// Get returns a copy of the facts remembered about a user, oldest first
+func (s *MemoryStore) Get(userID string) []MemoryFact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.users[userID])
+}
+
+// Update removes the facts with the given texts, adds new facts that are not already remembered
+// and persists the result. The oldest facts are dropped beyond the per-user limit.
+func (s *MemoryStore) Update(userID string, remove, add []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var facts []MemoryFact
+	for _, fact := range s.users[userID] {
+		if !slices.Contains(remove, fact.Text) {
+			facts = append(facts, fact)
+		}
+	}
+
+	now := time.Now()
+	for _, text := range add {
+		text = strings.TrimSpace(text)
+		if text == "" || len(text) > maxMemoryFactLength {
+			continue
+		}
+		known := slices.ContainsFunc(facts, func(fact MemoryFact) bool {
+			return strings.EqualFold(fact.Text, text)
+		})
+		if !known {
+			facts = append(facts, MemoryFact{Text: text, Saved: now})
+		}
+	}
+	if len(facts) > s.maxFacts {
+		facts = facts[len(facts)-s.maxFacts:]
+	}
+
+	previous, existed := s.users[userID]
+	if len(facts) == 0 {
+		delete(s.users, userID)
+	} else {
+		s.users[userID] = facts
+	}
+	if err := writeJSONFile(s.path, s.users); err != nil {
+		s.restoreLocked(userID, previous, existed)
+		return err
+	}
+	return nil
+}
+
+// Clear forgets everything about a user and persists the result
+func (s *MemoryStore) Clear(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.users[userID]
+	delete(s.users, userID)
+	if err := writeJSONFile(s.path, s.users); err != nil {
+		s.restoreLocked(userID, previous, existed)
+		return err
+	}
+	return nil
+}
+
+// restoreLocked puts back a user's facts after a failed save, so memory stays in line with
+// what is on disk. The caller must hold s.mu.
+func (s *MemoryStore) restoreLocked(userID string, facts []MemoryFact, existed bool) {
+	if existed {
+		s.users[userID] = facts
+	} else {
+		delete(s.users, userID)
+	}
+}
+
+// Prompt returns the memory section of the system prompt for a user, or "" if nothing is remembered
+func (s *MemoryStore) Prompt(userID, userName string) string {
+	facts := s.Get(userID)
+	if len(facts) == 0 {
+		return ""
+	}
+	if userName == "" {
+		userName = "the user"
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "What you remember about %s from earlier conversations:\n", userName)
+	for _, fact := range facts {
+		fmt.Fprintf(&prompt, "- %s\n", fact.Text)
+	}
+	return strings.TrimSuffix(prompt.String(), "\n")
+}
+
+// rememberFromTurn asks the memory model for facts worth keeping from a user's message and the
+// bot's reply and updates the user's memories. It is a no-op unless bot.memory.enabled is set.
+func rememberFromTurn(userID string, userMessage ChatMessage, reply string) {
+	if !config.Bot.Memory.Enabled || userID == "" {
+		return
+	}
+	text := messageText(userMessage)
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	facts := memoryStore.Get(userID)
+	var input strings.Builder
+	input.WriteString("Already remembered:\n")
+	if len(facts) == 0 {
+		input.WriteString("(nothing)\n")
+	}
+	for i, fact := range facts {
+		fmt.Fprintf(&input, "%d. %s\n", i+1, fact.Text)
+	}
+	fmt.Fprintf(&input, "\nUser (%s): %s\n\nBot: %s", userMessage.Username, text, reply)
+
+	var extraction memoryExtraction
+	err := grokClient.CompleteStruct(rootCtx, memoryExtractionPrompt, input.String(), &extraction, CompletionOptions{
+		Model: config.Bot.Memory.Model,
+	})
+	if err != nil {
+		log.Printf("Error extracting memories for user %s: %v", userID, err)
+		return
+	}
+	if len(extraction.Remember) == 0 && len(extraction.Forget) == 0 {
+		return
+	}
+
+	// The model numbers facts from 1
+	var remove []string
+	for _, number := range extraction.Forget {
+		if number >= 1 && number <= len(facts) {
+			remove = append(remove, facts[number-1].Text)
+		}
+	}
+	if err := memoryStore.Update(userID, remove, extraction.Remember); err != nil {
+		log.Printf("Error saving memories for user %s: %v", userID, err)
+	}
+}
+
+// memoryCommand defines the /memory slash command that lets users see and delete what the bot remembers
+func memoryCommand() slashCommand {
+	minNumber := 1.0
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:        "memory",
+			Description: "See or delete what the bot remembers about you",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show what the bot remembers about you",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "forget",
+					Description: "Delete one remembered fact",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "number",
+							Description: "The fact's number in /memory list",
+							Required:    true,
+							MinValue:    &minNumber,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "clear",
+					Description: "Delete everything the bot remembers about you",
+				},
+			},
+		},
+		handler: handleMemoryCommand,
+	}
+}
+
+// handleMemoryCommand answers /memory list, forget and clear for the calling user
+func handleMemoryCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	subcommand := interaction.ApplicationCommandData().Options[0]
+	user := interactionUser(interaction)
+	if user == nil {
+		return
+	}
+
+	switch subcommand.Name {
+	case "list":
+		facts := memoryStore.Get(user.ID)
+		if len(facts) == 0 {
+			respondEphemeral(discord, interaction, "I don't remember anything about you.")
+			return
+		}
+		var list strings.Builder
+		if !config.Bot.Memory.Enabled {
+			list.WriteString("Memory is disabled in the bot config, so these are not used.\n\n")
+		}
+		for i, fact := range facts {
+			fmt.Fprintf(&list, "%d. %s\n", i+1, fact.Text)
+		}
+		respondEphemeral(discord, interaction, truncateList(list.String()))
+
+	case "forget":
+		number := int(subcommand.Options[0].IntValue())
+		facts := memoryStore.Get(user.ID)
+		if number < 1 || number > len(facts) {
+			respondEphemeral(discord, interaction, "There's no fact with that number. Use `/memory list` to see them.")
+			return
+		}
+		if err := memoryStore.Update(user.ID, []string{facts[number-1].Text}, nil); err != nil {
+			log.Printf("Error saving memories for user %s: %v", user.ID, err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		respondEphemeral(discord, interaction, fmt.Sprintf("Forgot: %s", facts[number-1].Text))
+
+	case "clear":
+		if err := memoryStore.Clear(user.ID); err != nil {
+			log.Printf("Error saving memories for user %s: %v", user.ID, err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		respondEphemeral(discord, interaction, "I've forgotten everything about you.")
+	}
+}
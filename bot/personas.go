@@ -0,0 +1,205 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// personasFile is the name of the persisted per-channel persona choices inside bot.data_dir
+const personasFile = "personas.json"
+
+// maxPersonaChoices is Discord's limit on choices for a slash command option
+const maxPersonaChoices = 25
+
+// PersonaStore remembers which persona is active in each channel
+type PersonaStore struct {
+	mu       sync.Mutex
+	channels map[string]string // channel ID -> persona name
+	path     string
+}
+
+// NewPersonaStore constructs a PersonaStore persisting choices to path
+func NewPersonaStore(path string) *PersonaStore {
+	return &PersonaStore{
+		channels: make(map[string]string),
+		path:     path,
+	}
+}
+
+// Load reads persisted persona choices, if any
+func (s *PersonaStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := readJSONFile(s.path, &s.channels)
+	if s.channels == nil {
+		s.channels = make(map[string]string)
+	}
+	return err
+}
+
+// Get returns the persona name chosen for a channel, if any
+func (s *PersonaStore) Get(channelID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, ok := s.channels[channelID]
+	return name, ok
+}
+
+// Set chooses a persona for a channel, or with an empty name returns it to the default, and persists the choice
+func (s *PersonaStore) Set(channelID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.channels[channelID]
+	if name == "" {
+		delete(s.channels, channelID)
+	} else {
+		s.channels[channelID] = name
+	}
+	if err := writeJSONFile(s.path, s.channels); err != nil {
+		// Keep memory in line with what is saved
+		if existed {
+			s.channels[channelID] = previous
+		} else {
+			delete(s.channels, channelID)
+		}
+		return err
+	}
+	return nil
+}
+
+// findPersona returns the configured persona called name, ignoring case
+func findPersona(name string) *PersonaConfig {
+	for i := range config.Bot.Personas {
+		if strings.EqualFold(config.Bot.Personas[i].Name, name) {
+			return &config.Bot.Personas[i]
+		}
+	}
+	return nil
+}
+
+// activePersona returns the persona in effect for a channel: the one chosen with /persona,
+// else bot.default_persona, else nil for the plain default system message
+func activePersona(channelID string) *PersonaConfig {
+	if name, ok := personaStore.Get(channelID); ok {
+		if persona := findPersona(name); persona != nil {
+			return persona
+		}
+	}
+	if config.Bot.DefaultPersona != "" {
+		return findPersona(config.Bot.DefaultPersona)
+	}
+	return nil
+}
+
+// withPersona applies the model and temperature of the channel's active persona to opts
+func withPersona(channelID string, opts CompletionOptions) CompletionOptions {
+	persona := activePersona(channelID)
+	if persona == nil {
+		return opts
+	}
+	if persona.Model != "" && opts.Model == "" {
+		opts.Model = persona.Model
+	}
+	if persona.Temperature != nil && opts.Temperature == nil {
+		opts.Temperature = persona.Temperature
+	}
+	return opts
+}
+
+// personaCommand defines the /persona slash command
+func personaCommand() slashCommand {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, persona := range config.Bot.Personas {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: persona.Name, Value: persona.Name})
+	}
+
+	permissions := int64(discordgo.PermissionManageChannels)
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:                     "persona",
+			Description:              "Choose the bot's personality in this channel",
+			DefaultMemberPermissions: &permissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Switch this channel to a persona",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "The persona to use",
+							Required:    true,
+							Choices:     choices,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reset",
+					Description: "Return this channel to the default persona",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the available personas",
+				},
+			},
+		},
+		handler: handlePersonaCommand,
+	}
+}
+
+// handlePersonaCommand answers /persona set, reset and list
+func handlePersonaCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	subcommand := interaction.ApplicationCommandData().Options[0]
+	channelID := interaction.ChannelID
+
+	switch subcommand.Name {
+	case "set":
+		persona := findPersona(subcommand.Options[0].StringValue())
+		if persona == nil {
+			respondEphemeral(discord, interaction, "I don't know that persona.")
+			return
+		}
+		if err := personaStore.Set(channelID, persona.Name); err != nil {
+			log.Printf("Error saving persona for channel %s: %v", channelID, err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		respondEphemeral(discord, interaction, fmt.Sprintf("This channel now uses the **%s** persona.", persona.Name))
+
+	case "reset":
+		if err := personaStore.Set(channelID, ""); err != nil {
+			log.Printf("Error saving persona for channel %s: %v", channelID, err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		respondEphemeral(discord, interaction, "This channel is back to the default persona.")
+
+	case "list":
+		if len(config.Bot.Personas) == 0 {
+			respondEphemeral(discord, interaction, "No personas are configured.")
+			return
+		}
+		current := activePersona(channelID)
+		var list strings.Builder
+		for _, persona := range config.Bot.Personas {
+			fmt.Fprintf(&list, "**%s**", persona.Name)
+			if persona.Description != "" {
+				fmt.Fprintf(&list, " – %s", persona.Description)
+			}
+			if current != nil && current.Name == persona.Name {
+				list.WriteString(" *(active)*")
+			}
+			list.WriteString("\n")
+		}
+		respondEphemeral(discord, interaction, list.String())
+	}
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PromptData holds the variables available to system prompt templates
+type PromptData struct {
+	GuildName    string    // Name of the server, empty in DMs
+	ChannelName  string    // Name of the channel
+	ChannelTopic string    // Topic of the channel, if set
+	Now          time.Time // Current time in bot.timezone
+	Date         string    // Current date, e.g. "Monday, January 2, 2006"
+	Time         string    // Current time of day, e.g. "15:04 MST"
+	Timezone     string    // Name of bot.timezone
+	BotName      string    // The bot's display name in the server
+	UserName     string    // Name of the user the bot is answering, empty when unprompted
+	Emojis       string    // The server's emoji catalog, empty when emojis are disabled
+	Persona      string    // Name of the channel's active persona, empty for the default
+	Memories     string    // What the bot remembers about the user, empty when memory is disabled
+}
+
+// promptTemplates caches parsed system prompt templates by their source text
+var promptTemplates sync.Map
+
+// parsePromptTemplate parses a system prompt as a text/template, caching the result
+func parsePromptTemplate(text string) (*template.Template, error) {
+	if cached, ok := promptTemplates.Load(text); ok {
+		return cached.(*template.Template), nil
+	}
+
+	tmpl, err := template.New("system_message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	promptTemplates.Store(text, tmpl)
+	return tmpl, nil
+}
+
+// samplePromptData has every variable set, so that validation runs {{if}} branches that
+// empty values would skip
+var samplePromptData = PromptData{
+	GuildName:    "Example Server",
+	ChannelName:  "general",
+	ChannelTopic: "Anything goes",
+	Now:          time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC),
+	Date:         "Monday, January 2, 2006",
+	Time:         "15:04 UTC",
+	Timezone:     "UTC",
+	BotName:      "Grok",
+	UserName:     "someone",
+	Emojis:       "Custom emojis: <:wave:123>",
+	Persona:      "pirate",
+	Memories:     "What you remember about someone: likes Go",
+}
+
+// validatePromptTemplate checks that a system prompt parses and renders both with every variable
+// set and with every variable empty, so that typos in variable names fail when the config is loaded
+// whichever {{if}} branch they are in
+func validatePromptTemplate(text string) error {
+	tmpl, err := parsePromptTemplate(text)
+	if err != nil {
+		return err
+	}
+	for _, data := range []PromptData{samplePromptData, {}} {
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderPrompt executes a system prompt template, falling back to the raw text if it fails.
+// When the template does not use .Emojis or .Memories, non-empty values of them are appended to it.
+func renderPrompt(text string, data PromptData) string {
+	prompt := text
+	tmpl, err := parsePromptTemplate(text)
+	if err == nil {
+		var rendered strings.Builder
+		if err = tmpl.Execute(&rendered, data); err == nil {
+			prompt = rendered.String()
+		}
+	}
+	if err != nil {
+		log.Printf("Error rendering system prompt template: %v", err)
+	}
+
+	if data.Emojis != "" && !strings.Contains(text, ".Emojis") {
+		prompt += "\n\n" + data.Emojis
+	}
+	if data.Memories != "" && !strings.Contains(text, ".Memories") {
+		prompt += "\n\n" + data.Memories
+	}
+	return prompt
+}
+
+// promptDataFor gathers the template variables for a conversation in channelID with userName
+func promptDataFor(discord *discordgo.Session, channelID, userName string) PromptData {
+	now := time.Now().In(botLocation)
+	data := PromptData{
+		Now:      now,
+		Date:     now.Format("Monday, January 2, 2006"),
+		Time:     now.Format("15:04 MST"),
+		Timezone: botLocation.String(),
+		UserName: userName,
+	}
+	if discord == nil || discord.State == nil || discord.State.User == nil {
+		return data
+	}
+
+	data.BotName = discord.State.User.Username
+	if discord.State.User.GlobalName != "" {
+		data.BotName = discord.State.User.GlobalName
+	}
+
+	channel, err := discord.State.Channel(channelID)
+	if err != nil {
+		return data
+	}
+	data.ChannelName = channel.Name
+	data.ChannelTopic = channel.Topic
+
+	if channel.GuildID == "" {
+		return data
+	}
+	if guild, err := discord.State.Guild(channel.GuildID); err == nil {
+		data.GuildName = guild.Name
+	}
+	if member, err := discord.State.Member(channel.GuildID, discord.State.User.ID); err == nil && member.Nick != "" {
+		data.BotName = member.Nick
+	}
+	if config.Bot.EnableEmojis {
+		data.Emojis = emojiCatalog.Prompt(channel.GuildID)
+	}
+	return data
+}
+
+// systemPrompt renders the system message of the channel's active persona, or
+// bot.default_system_message, for a conversation in channelID with the user userID
+func systemPrompt(discord *discordgo.Session, channelID, userID, userName string) string {
+	data := promptDataFor(discord, channelID, userName)
+	if config.Bot.Memory.Enabled && userID != "" {
+		data.Memories = memoryStore.Prompt(userID, userName)
+	}
+	if persona := activePersona(channelID); persona != nil {
+		data.Persona = persona.Name
+		if persona.Nickname != "" {
+			data.BotName = persona.Nickname
+		}
+		return renderPrompt(persona.SystemMessage, data)
+	}
+	return renderPrompt(config.Bot.DefaultSystemMessage, data)
+}
+
+// describePromptVariables lists the template variables for error messages
+func describePromptVariables() string {
+	return fmt.Sprintf("available variables: %s", strings.Join([]string{
+		".GuildName", ".ChannelName", ".ChannelTopic", ".Now", ".Date", ".Time",
+		".Timezone", ".BotName", ".UserName", ".Emojis", ".Persona", ".Memories",
+	}, ", "))
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned by RequestQueue.Submit when a channel already has
+// the maximum number of pending requests.
+var ErrQueueFull = errors.New("channel request queue is full")
+
+// channelQueue holds the pending jobs for a single channel
+type channelQueue struct {
+	jobs    []func()
+	running bool // A drain goroutine is serving the channel
+	busy    bool // A job has been taken off jobs and has not finished yet
+}
+
+// RequestQueue serializes jobs within a channel while letting different channels
+// run in parallel, bounded by a global concurrency cap.
+type RequestQueue struct {
+	mu       sync.Mutex
+	slots    chan struct{}
+	maxDepth int
+	channels map[string]*channelQueue
+}
+
+// NewRequestQueue constructs a RequestQueue running at most maxConcurrent jobs at once
+// and holding at most maxDepth pending jobs per channel.
+func NewRequestQueue(maxConcurrent, maxDepth int) *RequestQueue {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+	return &RequestQueue{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxDepth: maxDepth,
+		channels: make(map[string]*channelQueue),
+	}
+}
+
+// Submit enqueues job for channelID and returns the number of jobs ahead of it
+// in that channel (0 means it starts right away, subject to the global cap).
+func (q *RequestQueue) Submit(channelID string, job func()) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cq, ok := q.channels[channelID]
+	if !ok {
+		cq = &channelQueue{}
+		q.channels[channelID] = cq
+	}
+	if len(cq.jobs) >= q.maxDepth {
+		return 0, ErrQueueFull
+	}
+
+	// Jobs the drain goroutine hasn't picked up yet are still in jobs, so only a
+	// job already taken off the queue counts on top of them
+	position := len(cq.jobs)
+	if cq.busy {
+		position++
+	}
+	cq.jobs = append(cq.jobs, job)
+
+	if !cq.running {
+		cq.running = true
+		go q.drain(channelID, cq)
+	}
+	return position, nil
+}
+
+// drain runs the jobs queued for a channel in order until none are left
+func (q *RequestQueue) drain(channelID string, cq *channelQueue) {
+	for {
+		q.mu.Lock()
+		cq.busy = false
+		if len(cq.jobs) == 0 {
+			cq.running = false
+			delete(q.channels, channelID)
+			q.mu.Unlock()
+			return
+		}
+		job := cq.jobs[0]
+		cq.jobs = cq.jobs[1:]
+		cq.busy = true
+		q.mu.Unlock()
+
+		q.slots <- struct{}{}
+		job()
+		<-q.slots
+	}
+}
@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestQueuePositions(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDepth  int
+		channels  []string // Channel of each submitted job, in order
+		positions []int    // Expected position of each job, -1 for ErrQueueFull
+	}{
+		{
+			name:      "first job starts right away",
+			maxDepth:  5,
+			channels:  []string{"a"},
+			positions: []int{0},
+		},
+		{
+			name:      "jobs queue behind the running one",
+			maxDepth:  5,
+			channels:  []string{"a", "a", "a"},
+			positions: []int{0, 1, 2},
+		},
+		{
+			name:      "channels queue independently",
+			maxDepth:  5,
+			channels:  []string{"a", "b", "a", "b"},
+			positions: []int{0, 0, 1, 1},
+		},
+		{
+			name:      "full channel rejects jobs",
+			maxDepth:  2,
+			channels:  []string{"a", "a", "a", "a"},
+			positions: []int{0, 1, 2, -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewRequestQueue(len(tt.channels), tt.maxDepth)
+			release := make(chan struct{})
+			defer close(release)
+
+			for i, channelID := range tt.channels {
+				position, err := queue.Submit(channelID, func() { <-release })
+				if tt.positions[i] < 0 {
+					if !errors.Is(err, ErrQueueFull) {
+						t.Fatalf("job %d: expected ErrQueueFull, got position %d, err %v", i, position, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("job %d: unexpected error %v", i, err)
+				}
+				if position != tt.positions[i] {
+					t.Errorf("job %d: expected position %d, got %d", i, tt.positions[i], position)
+				}
+				if i == 0 || tt.channels[i-1] != channelID {
+					// Let the channel's first job start so later positions don't depend on timing
+					waitForBusy(t, queue, channelID)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestQueueQuickSubmissions(t *testing.T) {
+	// The second job is next whether or not the first was taken off the queue yet
+	queue := NewRequestQueue(1, 5)
+	release := make(chan struct{})
+	defer close(release)
+
+	queue.Submit("a", func() { <-release })
+	position, err := queue.Submit("a", func() {})
+	if err != nil || position != 1 {
+		t.Fatalf("expected position 1, got %d, err %v", position, err)
+	}
+}
+
+func TestRequestQueueRunsJobsInOrder(t *testing.T) {
+	queue := NewRequestQueue(1, 10)
+	done := make(chan int, 3)
+	for i := range 3 {
+		if _, err := queue.Submit("a", func() { done <- i }); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	for want := range 3 {
+		select {
+		case got := <-done:
+			if got != want {
+				t.Fatalf("expected job %d, got %d", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for jobs")
+		}
+	}
+}
+
+// waitForBusy waits until a job in channelID has been taken off its queue
+func waitForBusy(t *testing.T, queue *RequestQueue, channelID string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		queue.mu.Lock()
+		cq, ok := queue.channels[channelID]
+		busy := ok && cq.busy
+		queue.mu.Unlock()
+		if busy {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("no job started in channel %s", channelID)
+}
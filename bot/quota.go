@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// UserQuota limits how many times each user may do something within a sliding window
+type UserQuota struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	uses   map[string][]time.Time
+}
+
+// NewUserQuota constructs a UserQuota allowing limit uses per window. A limit of 0 or less disables the quota.
+func NewUserQuota(limit int, window time.Duration) *UserQuota {
+	return &UserQuota{
+		limit:  limit,
+		window: window,
+		uses:   make(map[string][]time.Time),
+	}
+}
+
+// Allow records a use for userID if it is within the quota. When it is not, Allow
+// returns false and how long until the next use will be allowed.
+func (q *UserQuota) Allow(userID string) (bool, time.Duration) {
+	if q.limit <= 0 {
+		return true, 0
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	var recent []time.Time
+	for _, t := range q.uses[userID] {
+		if now.Sub(t) < q.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= q.limit {
+		q.uses[userID] = recent
+		return false, q.window - now.Sub(recent[0])
+	}
+
+	q.uses[userID] = append(recent, now)
+	return true, 0
+}
+
+// Refund takes back the most recent use recorded for userID, for when the action it allowed failed
+func (q *UserQuota) Refund(userID string) {
+	if q.limit <= 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if uses := q.uses[userID]; len(uses) > 0 {
+		q.uses[userID] = uses[:len(uses)-1]
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// lenientMessage holds the subset of a Discord message the bot needs. It deliberately
+// omits components so that messages containing component types discordgo does not
+// know can still be decoded.
+type lenientMessage struct {
+	ID               string                         `json:"id"`
+	ChannelID        string                         `json:"channel_id"`
+	GuildID          string                         `json:"guild_id"`
+	Content          string                         `json:"content"`
+	Timestamp        time.Time                      `json:"timestamp"`
+	Author           *discordgo.User                `json:"author"`
+	Mentions         []*discordgo.User              `json:"mentions"`
+	MentionRoles     []string                       `json:"mention_roles"`
+	Attachments      []*discordgo.MessageAttachment `json:"attachments"`
+	Embeds           []*discordgo.MessageEmbed      `json:"embeds"`
+	StickerItems     []*discordgo.StickerItem       `json:"sticker_items"`
+	MessageReference *discordgo.MessageReference    `json:"message_reference"`
+	Type             discordgo.MessageType          `json:"type"`
+}
+
+// toMessage converts the lenient form into a discordgo.Message
+func (m *lenientMessage) toMessage() *discordgo.Message {
+	return &discordgo.Message{
+		ID:               m.ID,
+		ChannelID:        m.ChannelID,
+		GuildID:          m.GuildID,
+		Content:          m.Content,
+		Timestamp:        m.Timestamp,
+		Author:           m.Author,
+		Mentions:         m.Mentions,
+		MentionRoles:     m.MentionRoles,
+		Attachments:      m.Attachments,
+		Embeds:           m.Embeds,
+		StickerItems:     m.StickerItems,
+		MessageReference: m.MessageReference,
+		Type:             m.Type,
+	}
+}
+
+// fetchMessagePageRaw fetches a page of channel messages through the raw REST API and
+// decodes each message on its own: fully when discordgo understands it, and leniently
+// (without components) when it does not. This keeps the whole page even when some
+// messages contain component types unknown to discordgo.
+func fetchMessagePageRaw(ctx context.Context, discord *discordgo.Session, channelID string, limit int, beforeID string) ([]*discordgo.Message, error) {
+	values := url.Values{}
+	values.Set("limit", strconv.Itoa(limit))
+	if beforeID != "" {
+		values.Set("before", beforeID)
+	}
+	endpoint := discordgo.EndpointChannelMessages(channelID)
+
+	body, err := discord.RequestWithBucketID("GET", endpoint+"?"+values.Encode(), nil, endpoint, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var rawMessages []json.RawMessage
+	if err := json.Unmarshal(body, &rawMessages); err != nil {
+		return nil, fmt.Errorf("failed to decode message page: %w", err)
+	}
+
+	messages := make([]*discordgo.Message, 0, len(rawMessages))
+	for _, raw := range rawMessages {
+		var full discordgo.Message
+		if err := json.Unmarshal(raw, &full); err == nil {
+			messages = append(messages, &full)
+			continue
+		}
+
+		var partial lenientMessage
+		if err := json.Unmarshal(raw, &partial); err != nil {
+			log.Printf("Skipping undecodable message in channel %s: %v", channelID, err)
+			continue
+		}
+		messages = append(messages, partial.toMessage())
+	}
+
+	return messages, nil
+}
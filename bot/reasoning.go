@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ways a reply can show the model's reasoning trace
+const (
+	ReasoningDisplayOff     = "off"
+	ReasoningDisplaySpoiler = "spoiler"
+	ReasoningDisplayFile    = "file"
+)
+
+// chatCommandName is the command name used for reasoning overrides of replies to mentions
+const chatCommandName = "chat"
+
+// isValidReasoningEffort reports whether effort is accepted by the reasoning_effort parameter.
+// Empty means the parameter is not sent.
+func isValidReasoningEffort(effort string) bool {
+	switch effort {
+	case "", "low", "high":
+		return true
+	default:
+		return false
+	}
+}
+
+// reasoningEffortFor returns the reasoning effort for a command run in a guild. A command
+// override wins over a guild override, which wins over the configured default.
+func reasoningEffortFor(guildID, command string) string {
+	if effort := config.Grok.CommandReasoningEffort[command]; effort != "" {
+		return effort
+	}
+	if effort := config.Grok.GuildReasoningEffort[guildID]; effort != "" {
+		return effort
+	}
+	return config.Grok.ReasoningEffort
+}
+
+// replyParts returns the message content and extra files that present a reply with its
+// reasoning trace according to the show_reasoning setting. A spoiler that does not fit
+// in a single message falls back to a file.
+func replyParts(content, reasoning string) (string, []*discordgo.File) {
+	if reasoning == "" || config.Bot.ShowReasoning == ReasoningDisplayOff {
+		return content, nil
+	}
+
+	if config.Bot.ShowReasoning == ReasoningDisplaySpoiler {
+		withSpoiler := fmt.Sprintf("%s\n\n**Reasoning:** ||%s||", content, strings.ReplaceAll(reasoning, "||", "|\u200b|"))
+		if len(withSpoiler) <= config.Bot.MaxMessageSize {
+			return withSpoiler, nil
+		}
+	}
+
+	return content, []*discordgo.File{{
+		Name:        reasoningFilename(),
+		ContentType: "text/markdown",
+		Reader:      strings.NewReader(reasoning),
+	}}
+}
+
+// reasoningFilename returns a timestamped filename for reasoning traces sent as files
+func reasoningFilename() string {
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	return fmt.Sprintf("grok_reasoning_%s.md", timestamp)
+}
+
+// sendReply sends a response to a channel together with its reasoning trace, if it should be shown
+func sendReply(discord *discordgo.Session, channelID, content, reasoning string) (*discordgo.Message, error) {
+	content, files := replyParts(content, reasoning)
+	if len(files) == 0 {
+		return sendMessage(discord, channelID, content)
+	}
+
+	message := &discordgo.MessageSend{Content: content, Files: files}
+	if len(content) > config.Bot.MaxMessageSize {
+		if len(content) > MaxDiscordFileSize {
+			return nil, fmt.Errorf("response too large even for file upload (%d bytes)", len(content))
+		}
+		message.Content = ""
+		message.Files = append([]*discordgo.File{{
+			Name:        markdownFilename(),
+			ContentType: "text/markdown",
+			Reader:      strings.NewReader(content),
+		}}, files...)
+	}
+	return postMessage(discord, channelID, message)
+}
@@ -0,0 +1,482 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// remindersFile is the name of the persisted reminders inside bot.data_dir
+const remindersFile = "reminders.json"
+
+// maxReminderLength caps the text of a reminder
+const maxReminderLength = 1000
+
+// reminderPollInterval is the longest the reminder loop sleeps, so clock changes are picked up
+const reminderPollInterval = time.Hour
+
+// reminderIntentPattern matches messages addressed to the bot that probably ask for a reminder;
+// the extraction model makes the final call
+var reminderIntentPattern = regexp.MustCompile(`(?i)\b(remind (me|us)|set (a|an) reminder)\b`)
+
+// errNotAReminder is returned by setReminder when the model finds no reminder in the request
+var errNotAReminder = errors.New("not a reminder request")
+
+// reminderExtractionPrompt instructs the model turning a request into a reminder
+const reminderExtractionPrompt = `You read a message sent to a Discord bot and decide whether it asks the bot to remind the sender of something at a later time. If it does, work out when and what.
+
+Resolve relative times ("in 20 minutes", "tomorrow at 3", "next Friday morning") against the current time and timezone you are given, and answer with the time in that timezone. When an hour could be morning or afternoon, pick the one between 08:00 and 20:00; "morning" means 09:00, "afternoon" 14:00, "evening" 18:00 and "tonight" 20:00. Leave the time empty if the message gives no way to tell when.
+
+Write the text as a short note to the sender about what to do, e.g. "deploy the release" for "remind me tomorrow at 3 to deploy the release".`
+
+// reminderExtraction is the structured output of the reminder extraction model
+type reminderExtraction struct {
+	IsReminder bool   `json:"is_reminder" description:"Whether the message asks to be reminded of something later"`
+	Time       string `json:"time" description:"When to remind, as RFC 3339 with the timezone's UTC offset, e.g. 2026-10-19T15:00:00-04:00; empty if unclear"`
+	Text       string `json:"text" description:"What to remind about, as a short note to the sender"`
+	DM         bool   `json:"dm" description:"Whether the sender asked to be reminded privately or by direct message"`
+}
+
+// Reminder is a note the bot sends a user at a set time
+type Reminder struct {
+	ID        int       `json:"id"`
+	UserID    string    `json:"user_id"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id,omitempty"` // The message that asked for the reminder, linked when it fires
+	Text      string    `json:"text"`
+	At        time.Time `json:"at"`
+	DM        bool      `json:"dm"` // Send by direct message instead of mentioning the user in the channel
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// storedReminders is the on-disk form of a ReminderStore
+type storedReminders struct {
+	NextID    int         `json:"next_id"`
+	Reminders []*Reminder `json:"reminders"`
+}
+
+// ReminderStore keeps pending reminders and sends them when they are due
+type ReminderStore struct {
+	mu         sync.Mutex
+	reminders  []*Reminder
+	nextID     int
+	maxPerUser int
+	path       string
+	wake       chan struct{} // Signals Run that an earlier reminder may have been added
+}
+
+// NewReminderStore constructs a ReminderStore allowing maxPerUser pending reminders per user,
+// persisting them to path
+func NewReminderStore(maxPerUser int, path string) *ReminderStore {
+	return &ReminderStore{nextID: 1, maxPerUser: maxPerUser, path: path, wake: make(chan struct{}, 1)}
+}
+
+// Load reads persisted reminders, if any
+func (s *ReminderStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored storedReminders
+	if _, err := readJSONFile(s.path, &stored); err != nil {
+		return err
+	}
+	s.reminders = stored.Reminders
+	s.nextID = max(stored.NextID, 1)
+	return nil
+}
+
+// saveLocked persists the reminders; the caller must hold s.mu
+func (s *ReminderStore) saveLocked() error {
+	return writeJSONFile(s.path, storedReminders{NextID: s.nextID, Reminders: s.reminders})
+}
+
+// Add assigns the reminder an ID, stores it and persists the reminders. It fails if the user
+// already has the maximum number of pending reminders.
+func (s *ReminderStore) Add(reminder Reminder) (Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, existing := range s.reminders {
+		if existing.UserID == reminder.UserID {
+			count++
+		}
+	}
+	if count >= s.maxPerUser {
+		return Reminder{}, fmt.Errorf("you already have %d reminders; cancel one with /reminders cancel", count)
+	}
+
+	reminder.ID = s.nextID
+	s.nextID++
+	s.reminders = append(s.reminders, &reminder)
+	if err := s.saveLocked(); err != nil {
+		// Undo the add so a reminder the user was told failed is never sent
+		s.reminders = s.reminders[:len(s.reminders)-1]
+		s.nextID--
+		return Reminder{}, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return reminder, nil
+}
+
+// Cancel deletes a user's reminder by ID and persists the reminders, reporting whether it existed
+func (s *ReminderStore) Cancel(userID string, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.reminders, func(reminder *Reminder) bool {
+		return reminder.ID == id && reminder.UserID == userID
+	})
+	if index < 0 {
+		return false, nil
+	}
+	reminder := s.reminders[index]
+	s.reminders = slices.Delete(s.reminders, index, index+1)
+	if err := s.saveLocked(); err != nil {
+		// Keep the reminder so what fires matches what is saved
+		s.reminders = slices.Insert(s.reminders, index, reminder)
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns copies of a user's pending reminders, soonest first
+func (s *ReminderStore) List(userID string) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Reminder
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID {
+			out = append(out, *reminder)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out
+}
+
+// Due removes the reminders due at now and returns them, so each is sent at most once
+func (s *ReminderStore) Due(now time.Time) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []Reminder
+	pending := s.reminders[:0]
+	for _, reminder := range s.reminders {
+		if reminder.At.After(now) {
+			pending = append(pending, reminder)
+		} else {
+			due = append(due, *reminder)
+		}
+	}
+	s.reminders = pending
+	if len(due) > 0 {
+		if err := s.saveLocked(); err != nil {
+			log.Printf("Error saving reminders: %v", err)
+		}
+	}
+	return due
+}
+
+// nextAt returns when the earliest pending reminder is due
+func (s *ReminderStore) nextAt() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, reminder := range s.reminders {
+		if next.IsZero() || reminder.At.Before(next) {
+			next = reminder.At
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Run sends reminders as they come due until ctx is done. Reminders that came due while the
+// bot was offline are sent on startup.
+func (s *ReminderStore) Run(ctx context.Context, discord *discordgo.Session) {
+	for {
+		for _, reminder := range s.Due(time.Now()) {
+			deliverReminder(discord, reminder)
+		}
+
+		wait := reminderPollInterval
+		if next, ok := s.nextAt(); ok {
+			wait = min(wait, time.Until(next))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// deliverReminder mentions the user in the reminder's channel, or sends it by DM when they asked
+// for that or the channel can't be posted in
+func deliverReminder(discord *discordgo.Session, reminder Reminder) {
+	content := fmt.Sprintf("<@%s> Reminder: %s", reminder.UserID, reminder.Text)
+	if time.Since(reminder.At) > time.Minute {
+		content += fmt.Sprintf(" (due <t:%d:R>)", reminder.At.Unix())
+	}
+	if reminder.MessageID != "" {
+		// Angle brackets stop Discord from embedding the linked message
+		content += fmt.Sprintf(" ([jump](<%s>))", messageLink(reminder.GuildID, reminder.ChannelID, reminder.MessageID))
+	}
+	message := &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{reminder.UserID}},
+	}
+
+	if !reminder.DM {
+		_, err := postMessage(discord, reminder.ChannelID, message)
+		if err == nil {
+			return
+		}
+		log.Printf("Error posting reminder %d in channel %s, sending it by DM: %v", reminder.ID, reminder.ChannelID, err)
+	}
+
+	channel, err := discord.UserChannelCreate(reminder.UserID)
+	if err != nil {
+		log.Printf("Error opening DM for reminder %d: %v", reminder.ID, err)
+		return
+	}
+	if _, err := discord.ChannelMessageSendComplex(channel.ID, message); err != nil {
+		log.Printf("Error sending reminder %d by DM: %v", reminder.ID, err)
+	}
+}
+
+// setReminder asks the extraction model for the time and text of a reminder request and stores
+// the reminder. It returns errNotAReminder when the request isn't one; other errors explain to
+// the user what went wrong.
+func setReminder(ctx context.Context, reminder Reminder, userName, request string) (Reminder, error) {
+	now := time.Now().In(botLocation)
+	input := fmt.Sprintf("Current time: %s (%s, UTC%s)\n\n%s: %s",
+		now.Format("Monday, 2006-01-02 15:04"), botLocation, now.Format("-07:00"), userName, request)
+
+	var extraction reminderExtraction
+	err := grokClient.CompleteStruct(ctx, reminderExtractionPrompt, input, &extraction, CompletionOptions{
+		Model: config.Bot.Reminders.Model,
+	})
+	if err != nil {
+		log.Printf("Error extracting reminder: %v", err)
+		return Reminder{}, fmt.Errorf("I couldn't read that request, please try again")
+	}
+	if !extraction.IsReminder {
+		return Reminder{}, errNotAReminder
+	}
+
+	at, err := parseReminderTime(extraction.Time)
+	if err != nil {
+		return Reminder{}, fmt.Errorf(`I couldn't tell when to remind you; try something like "tomorrow at 15:00"`)
+	}
+	if !at.After(now) {
+		return Reminder{}, fmt.Errorf("<t:%d:F> has already passed", at.Unix())
+	}
+
+	reminder.Text = strings.TrimSpace(extraction.Text)
+	if reminder.Text == "" {
+		return Reminder{}, fmt.Errorf("I couldn't tell what to remind you about")
+	}
+	if len(reminder.Text) > maxReminderLength {
+		reminder.Text = strings.ToValidUTF8(reminder.Text[:maxReminderLength], "") + "..."
+	}
+	reminder.At = at
+	reminder.DM = reminder.DM || extraction.DM
+	reminder.CreatedAt = time.Now()
+	return reminders.Add(reminder)
+}
+
+// parseReminderTime parses the extracted time, taking times without a UTC offset to be in bot.timezone
+func parseReminderTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", value, botLocation)
+}
+
+// describeReminder confirms a reminder, with its time as a Discord timestamp shown in each reader's timezone
+func describeReminder(reminder Reminder) string {
+	where := "here"
+	if reminder.DM {
+		where = "by DM"
+	}
+	return fmt.Sprintf("I'll remind you %s <t:%d:F> (<t:%d:R>): %s", where, reminder.At.Unix(), reminder.At.Unix(), reminder.Text)
+}
+
+// respondToReminder sets a reminder for a "@grok remind me ..." message and confirms it, or
+// answers it as a normal message if the model finds no reminder in it. It runs on the
+// channel's request queue.
+func respondToReminder(discord *discordgo.Session, message *discordgo.MessageCreate, userMessage ChatMessage) {
+	channelID := message.ChannelID
+
+	stopTyping := keepTyping(rootCtx, discord, channelID)
+	reminder, err := setReminder(rootCtx, Reminder{
+		UserID:    message.Author.ID,
+		GuildID:   message.GuildID,
+		ChannelID: channelID,
+		MessageID: message.ID,
+	}, message.Author.Username, messageText(userMessage))
+	stopTyping()
+	if errors.Is(err, errNotAReminder) {
+		respondToMessage(discord, message, userMessage)
+		return
+	}
+
+	content := describeReminder(reminder)
+	if err != nil {
+		content = fmt.Sprintf("I couldn't set that reminder: %v.", err)
+	}
+	reply, err := sendMessage(discord, channelID, content)
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+
+	assistantMessage := CreateTextMessage("assistant", content, "")
+	if reply != nil {
+		assistantMessage.MessageID = reply.ID
+	}
+	chatHistory.Append(channelID, withoutFileTexts(userMessage))
+	chatHistory.Append(channelID, assistantMessage)
+}
+
+// remindersCommand defines the /reminders slash command
+func remindersCommand() slashCommand {
+	minID := 1.0
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:        "reminders",
+			Description: "Set, list or cancel your reminders",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Set a reminder",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "reminder",
+							Description: `When and what, e.g. "tomorrow at 3 to deploy"`,
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "dm",
+							Description: "Send the reminder by DM instead of mentioning you here",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show your pending reminders",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cancel",
+					Description: "Cancel a reminder",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "The reminder's number in /reminders list",
+							Required:    true,
+							MinValue:    &minID,
+						},
+					},
+				},
+			},
+		},
+		handler: handleRemindersCommand,
+	}
+}
+
+// handleRemindersCommand answers /reminders add, list and cancel for the calling user
+func handleRemindersCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	subcommand := interaction.ApplicationCommandData().Options[0]
+	user := interactionUser(interaction)
+	if user == nil {
+		return
+	}
+
+	switch subcommand.Name {
+	case "add":
+		reminder := Reminder{UserID: user.ID, GuildID: interaction.GuildID, ChannelID: interaction.ChannelID}
+		request := ""
+		for _, option := range subcommand.Options {
+			switch option.Name {
+			case "reminder":
+				request = strings.TrimSpace(option.StringValue())
+			case "dm":
+				reminder.DM = option.BoolValue()
+			}
+		}
+
+		if err := deferEphemeralResponse(discord, interaction); err != nil {
+			log.Printf("Error deferring /reminders response: %v", err)
+			return
+		}
+		// Slash commands are explicit requests, so phrasing without "remind me" still counts
+		if !reminderIntentPattern.MatchString(request) {
+			request = "Remind me " + request
+		}
+		reminder, err := setReminder(rootCtx, reminder, user.Username, request)
+		switch {
+		case errors.Is(err, errNotAReminder):
+			editResponse(discord, interaction, `I couldn't find a reminder in that; try something like "tomorrow at 3 to deploy".`)
+		case err != nil:
+			editResponse(discord, interaction, fmt.Sprintf("I couldn't set that reminder: %v.", err))
+		default:
+			editResponse(discord, interaction, fmt.Sprintf("Reminder #%d set. %s", reminder.ID, describeReminder(reminder)))
+		}
+
+	case "list":
+		pending := reminders.List(user.ID)
+		if len(pending) == 0 {
+			respondEphemeral(discord, interaction, "You have no pending reminders.")
+			return
+		}
+		var list strings.Builder
+		for _, reminder := range pending {
+			where := fmt.Sprintf("in <#%s>", reminder.ChannelID)
+			if reminder.DM {
+				where = "by DM"
+			}
+			fmt.Fprintf(&list, "**#%d** <t:%d:F> %s: %s\n", reminder.ID, reminder.At.Unix(), where, reminder.Text)
+		}
+		respondEphemeral(discord, interaction, truncateList(list.String()))
+
+	case "cancel":
+		id := int(subcommand.Options[0].IntValue())
+		cancelled, err := reminders.Cancel(user.ID, id)
+		if err != nil {
+			log.Printf("Error saving reminders: %v", err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		if !cancelled {
+			respondEphemeral(discord, interaction, fmt.Sprintf("You have no reminder #%d.", id))
+			return
+		}
+		respondEphemeral(discord, interaction, fmt.Sprintf("Cancelled reminder #%d.", id))
+	}
+}
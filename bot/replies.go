@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// replyRecord links a message that addressed the bot to the bot's reply
+type replyRecord struct {
+	ChannelID string
+	ReplyID   string
+	Content   string // trigger content the reply was generated from
+	CreatedAt time.Time
+}
+
+// ReplyTracker maps trigger message IDs to the IDs of the bot's replies so
+// that edits to a trigger can regenerate the reply in place.
+// Records older than the configured window are discarded.
+type ReplyTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	replies map[string]replyRecord
+}
+
+// NewReplyTracker constructs a ReplyTracker that remembers replies for window.
+func NewReplyTracker(window time.Duration) *ReplyTracker {
+	return &ReplyTracker{
+		window:  window,
+		replies: make(map[string]replyRecord),
+	}
+}
+
+// Track records that replyID in channelID answered triggerID with the given content.
+// Re-tracking an existing trigger updates it but keeps the original window start.
+func (t *ReplyTracker) Track(triggerID, channelID, replyID, content string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.pruneLocked(now)
+	createdAt := now
+	if existing, ok := t.replies[triggerID]; ok {
+		createdAt = existing.CreatedAt
+	}
+	t.replies[triggerID] = replyRecord{
+		ChannelID: channelID,
+		ReplyID:   replyID,
+		Content:   content,
+		CreatedAt: createdAt,
+	}
+}
+
+// Lookup returns the reply recorded for triggerID if it is still within the window.
+func (t *ReplyTracker) Lookup(triggerID string) (replyRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.replies[triggerID]
+	if !ok {
+		return replyRecord{}, false
+	}
+	if time.Since(record.CreatedAt) > t.window {
+		delete(t.replies, triggerID)
+		return replyRecord{}, false
+	}
+	return record, true
+}
+
+// pruneLocked drops expired records. Callers must hold t.mu.
+func (t *ReplyTracker) pruneLocked(now time.Time) {
+	for id, record := range t.replies {
+		if now.Sub(record.CreatedAt) > t.window {
+			delete(t.replies, id)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 tuning constants, the usual defaults
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// stopWords are common English words ignored by lexical search
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "did": true, "do": true, "does": true, "for": true, "from": true, "had": true, "has": true,
+	"have": true, "how": true, "i": true, "in": true, "is": true, "it": true, "its": true, "me": true,
+	"my": true, "of": true, "on": true, "or": true, "our": true, "so": true, "that": true, "the": true,
+	"their": true, "them": true, "then": true, "there": true, "this": true, "to": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "where": true, "which": true, "who": true,
+	"why": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// tokenize lowercases text and splits it into words for lexical search, dropping stop words
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if len(word) > 1 && !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// bm25Scores scores tokenized documents against a tokenized query with Okapi BM25.
+// Documents sharing no terms with the query score 0.
+func bm25Scores(query []string, documents [][]string) []float64 {
+	scores := make([]float64, len(documents))
+	if len(query) == 0 || len(documents) == 0 {
+		return scores
+	}
+
+	totalLength := 0
+	for _, document := range documents {
+		totalLength += len(document)
+	}
+	averageLength := float64(totalLength) / float64(len(documents))
+	if averageLength == 0 {
+		return scores
+	}
+
+	terms := make(map[string]bool)
+	for _, term := range query {
+		terms[term] = true
+	}
+
+	// Count term frequencies per document and document frequencies per term
+	frequencies := make([]map[string]int, len(documents))
+	documentFrequency := make(map[string]int)
+	for i, document := range documents {
+		counts := make(map[string]int)
+		for _, token := range document {
+			if terms[token] {
+				counts[token]++
+			}
+		}
+		for term := range counts {
+			documentFrequency[term]++
+		}
+		frequencies[i] = counts
+	}
+
+	n := float64(len(documents))
+	for i, document := range documents {
+		length := float64(len(document))
+		for term := range terms {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/averageLength))
+		}
+	}
+	return scores
+}
+
+// cosineSimilarity returns the cosine of the angle between two embeddings, or 0 if they differ in size
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// rankDocuments scores documents against a query: by embedding similarity when queryEmbedding is
+// given and a document has an embedding of the same size, otherwise by BM25 over its tokens.
+// Nearly all texts are somewhat similar, so similarities below minSimilarity score 0.
+func rankDocuments(query string, queryEmbedding []float32, tokens [][]string, embeddings [][]float32, minSimilarity float64) []float64 {
+	if len(queryEmbedding) == 0 {
+		return bm25Scores(tokenize(query), tokens)
+	}
+
+	scores := make([]float64, len(tokens))
+	var lexical []int
+	for i, embedding := range embeddings {
+		if len(embedding) == len(queryEmbedding) {
+			if similarity := cosineSimilarity(queryEmbedding, embedding); similarity >= minSimilarity {
+				scores[i] = similarity
+			}
+		} else {
+			lexical = append(lexical, i)
+		}
+	}
+	if len(lexical) > 0 {
+		documents := make([][]string, len(lexical))
+		for j, i := range lexical {
+			documents[j] = tokens[i]
+		}
+		// BM25 scores are unbounded; squash them below typical similarities of good embedding matches
+		for j, score := range bm25Scores(tokenize(query), documents) {
+			scores[lexical[j]] = score / (score + 10)
+		}
+	}
+	return scores
+}
+
+// topScores returns the indexes of the k highest scores above minScore, best first
+func topScores(scores []float64, k int, minScore float64) []int {
+	var indexes []int
+	for i, score := range scores {
+		if score > minScore {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return scores[indexes[i]] > scores[indexes[j]]
+	})
+	if len(indexes) > k {
+		indexes = indexes[:k]
+	}
+	return indexes
+}
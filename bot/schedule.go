@@ -0,0 +1,397 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// schedulesFile is the name of the persisted scheduled jobs inside bot.data_dir
+const schedulesFile = "schedules.json"
+
+// scheduleCommandName is the name of the /schedule command, also used for reasoning effort overrides
+const scheduleCommandName = "schedule"
+
+// maxListedPromptLength caps how much of each prompt /schedule list shows
+const maxListedPromptLength = 100
+
+// ScheduledJob is a prompt the bot runs on a cron schedule and posts the answer of to a channel
+type ScheduledJob struct {
+	ID        int       `json:"id"`
+	GuildID   string    `json:"guild_id"`
+	ChannelID string    `json:"channel_id"`
+	Cron      string    `json:"cron"`
+	Prompt    string    `json:"prompt"`
+	History   bool      `json:"history"` // Include the channel's messages since the previous run
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+
+	schedule *CronSchedule
+}
+
+// storedSchedules is the on-disk form of a Scheduler
+type storedSchedules struct {
+	NextID int             `json:"next_id"`
+	Jobs   []*ScheduledJob `json:"jobs"`
+}
+
+// Scheduler keeps the scheduled jobs of all guilds and runs them when they are due
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []*ScheduledJob
+	nextID int
+	path   string
+}
+
+// NewScheduler constructs a Scheduler persisting jobs to path
+func NewScheduler(path string) *Scheduler {
+	return &Scheduler{nextID: 1, path: path}
+}
+
+// Load reads persisted jobs, if any; jobs whose schedule no longer parses are dropped
+func (s *Scheduler) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored storedSchedules
+	if _, err := readJSONFile(s.path, &stored); err != nil {
+		return err
+	}
+	for _, job := range stored.Jobs {
+		schedule, err := ParseCron(job.Cron)
+		if err != nil {
+			log.Printf("Dropping scheduled job %d with invalid schedule %q: %v", job.ID, job.Cron, err)
+			continue
+		}
+		job.schedule = schedule
+		s.jobs = append(s.jobs, job)
+	}
+	s.nextID = max(stored.NextID, 1)
+	return nil
+}
+
+// saveLocked persists the jobs; the caller must hold s.mu
+func (s *Scheduler) saveLocked() error {
+	return writeJSONFile(s.path, storedSchedules{NextID: s.nextID, Jobs: s.jobs})
+}
+
+// Add assigns the job an ID, stores it and persists the jobs. It fails if the job's schedule
+// is invalid or its guild already has bot.schedule.max_jobs jobs.
+func (s *Scheduler) Add(job ScheduledJob) (ScheduledJob, error) {
+	schedule, err := ParseCron(job.Cron)
+	if err != nil {
+		return ScheduledJob{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, existing := range s.jobs {
+		if existing.GuildID == job.GuildID {
+			count++
+		}
+	}
+	if count >= config.Bot.Schedule.MaxJobs {
+		return ScheduledJob{}, fmt.Errorf("this server already has %d scheduled jobs", count)
+	}
+
+	job.ID = s.nextID
+	job.schedule = schedule
+	s.nextID++
+	s.jobs = append(s.jobs, &job)
+	if err := s.saveLocked(); err != nil {
+		// Undo the add so a job the user was told failed never runs
+		s.jobs = s.jobs[:len(s.jobs)-1]
+		s.nextID--
+		return ScheduledJob{}, err
+	}
+	return job, nil
+}
+
+// Remove deletes a guild's job by ID and persists the jobs, reporting whether it existed
+func (s *Scheduler) Remove(guildID string, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.jobs, func(job *ScheduledJob) bool {
+		return job.ID == id && job.GuildID == guildID
+	})
+	if index < 0 {
+		return false, nil
+	}
+	job := s.jobs[index]
+	s.jobs = slices.Delete(s.jobs, index, index+1)
+	if err := s.saveLocked(); err != nil {
+		// Keep the job so what runs matches what is saved
+		s.jobs = slices.Insert(s.jobs, index, job)
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns copies of a guild's jobs in the order they were added
+func (s *Scheduler) List(guildID string) []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []ScheduledJob
+	for _, job := range s.jobs {
+		if job.GuildID == guildID {
+			out = append(out, *job)
+		}
+	}
+	return out
+}
+
+// Due returns copies of the jobs that fire in the minute containing now and marks them as run.
+// The copies keep the previous LastRun so a job can read what happened since then.
+func (s *Scheduler) Due(now time.Time) []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	minute := now.Truncate(time.Minute)
+	var due []ScheduledJob
+	for _, job := range s.jobs {
+		if job.schedule.Matches(now) && job.LastRun.Before(minute) {
+			due = append(due, *job)
+			job.LastRun = minute
+		}
+	}
+	if len(due) > 0 {
+		if err := s.saveLocked(); err != nil {
+			log.Printf("Error saving scheduled jobs: %v", err)
+		}
+	}
+	return due
+}
+
+// Run checks for due jobs at the start of every minute, in bot.timezone, until ctx is done and
+// queues them on their channel's request queue
+func (s *Scheduler) Run(ctx context.Context, discord *discordgo.Session) {
+	for {
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+		}
+
+		for _, job := range s.Due(time.Now().In(botLocation)) {
+			_, err := requestQueue.Submit(job.ChannelID, func() { runScheduledJob(ctx, discord, job) })
+			if err != nil {
+				log.Printf("Skipping scheduled job %d in channel %s: %v", job.ID, job.ChannelID, err)
+			}
+		}
+	}
+}
+
+// runScheduledJob answers a job's prompt, with the channel's messages since the previous run
+// when the job asks for them, and posts the answer to the job's channel
+func runScheduledJob(ctx context.Context, discord *discordgo.Session, job ScheduledJob) {
+	prompt := job.Prompt
+	var referenced []*discordgo.Message
+	if job.History {
+		since := job.LastRun
+		if since.IsZero() {
+			since = job.CreatedAt
+		}
+		messages, err := fetchMessagesSince(ctx, discord, job.ChannelID, snowflakeAt(since), config.Bot.Summarize.MaxMessages)
+		if err != nil && len(messages) == 0 {
+			log.Printf("Error fetching messages for scheduled job %d: %v", job.ID, err)
+			return
+		}
+
+		lines, refs := transcriptLines(discord, messages)
+		transcript := strings.Join(lines, "\n")
+		switch {
+		case len(lines) == 0:
+			prompt += "\n\nThere have been no messages in the channel since the last run."
+		case len(transcript) <= config.Bot.Summarize.ChunkSize:
+			referenced = refs
+			prompt += "\n\nChannel messages since the last run. Each starts with a reference like [m12]; put the reference of the message a point comes from after it:\n" + transcript
+		default:
+			digest, _, err := summarizeMessages(ctx, discord, job.GuildID, job.ChannelID, messages)
+			if err != nil {
+				log.Printf("Error summarizing messages for scheduled job %d: %v", job.ID, err)
+				return
+			}
+			prompt += "\n\nSummary of the channel messages since the last run:\n" + digest
+		}
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: systemPrompt(discord, job.ChannelID, "", "")},
+		{Role: "user", Content: prompt},
+	}
+	completion, err := grokClient.CreateChatCompletionDetailed(ctx, messages, withPersona(job.ChannelID, CompletionOptions{
+		ReasoningEffort: reasoningEffortFor(job.GuildID, scheduleCommandName),
+	}))
+	if err != nil {
+		log.Printf("Error getting Grok response for scheduled job %d: %v", job.ID, err)
+		return
+	}
+	content := emojiCatalog.Repair(job.GuildID, completion.Content)
+	content = linkReferences(content, job.GuildID, job.ChannelID, referenced)
+
+	reply, err := sendMessage(discord, job.ChannelID, content)
+	if err != nil {
+		log.Printf("Error posting scheduled job %d: %v", job.ID, err)
+		return
+	}
+
+	assistantMessage := CreateTextMessage("assistant", content, "")
+	assistantMessage.MessageID = reply.ID
+	chatHistory.Append(job.ChannelID, assistantMessage)
+}
+
+// scheduleCommand defines the /schedule slash command
+func scheduleCommand() slashCommand {
+	permissions := int64(discordgo.PermissionManageChannels)
+	dmPermission := false
+	minID := 1.0
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:                     scheduleCommandName,
+			Description:              "Run prompts on a schedule, like a daily digest",
+			DefaultMemberPermissions: &permissions,
+			DMPermission:             &dmPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Schedule a prompt",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "cron",
+							Description: `When to run, as a cron expression like "0 9 * * 1-5" or @daily`,
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "prompt",
+							Description: "What to ask the bot each time",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "history",
+							Description: "Give the bot the channel's messages since the previous run",
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Where to post (defaults to this channel)",
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show this server's scheduled prompts",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Delete a scheduled prompt",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "The job's number in /schedule list",
+							Required:    true,
+							MinValue:    &minID,
+						},
+					},
+				},
+			},
+		},
+		handler: handleScheduleCommand,
+	}
+}
+
+// handleScheduleCommand answers /schedule add, list and remove
+func handleScheduleCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	subcommand := interaction.ApplicationCommandData().Options[0]
+
+	switch subcommand.Name {
+	case "add":
+		job := ScheduledJob{
+			GuildID:   interaction.GuildID,
+			ChannelID: interaction.ChannelID,
+			CreatedBy: interactionUser(interaction).ID,
+			CreatedAt: time.Now(),
+		}
+		for _, option := range subcommand.Options {
+			switch option.Name {
+			case "cron":
+				job.Cron = strings.TrimSpace(option.StringValue())
+			case "prompt":
+				job.Prompt = strings.TrimSpace(option.StringValue())
+			case "history":
+				job.History = option.BoolValue()
+			case "channel":
+				job.ChannelID = option.ChannelValue(nil).ID
+			}
+		}
+
+		added, err := scheduler.Add(job)
+		if err != nil {
+			respondEphemeral(discord, interaction, fmt.Sprintf("I couldn't schedule that: %v", err))
+			return
+		}
+		respondEphemeral(discord, interaction, fmt.Sprintf("Scheduled job #%d in <#%s>. %s", added.ID, added.ChannelID, describeNextRun(added)))
+
+	case "list":
+		jobs := scheduler.List(interaction.GuildID)
+		if len(jobs) == 0 {
+			respondEphemeral(discord, interaction, "Nothing is scheduled in this server.")
+			return
+		}
+		var list strings.Builder
+		for _, job := range jobs {
+			prompt := job.Prompt
+			if len(prompt) > maxListedPromptLength {
+				prompt = strings.ToValidUTF8(prompt[:maxListedPromptLength], "") + "..."
+			}
+			history := ""
+			if job.History {
+				history = ", with channel history"
+			}
+			fmt.Fprintf(&list, "**#%d** `%s` in <#%s>%s. %s\n> %s\n", job.ID, job.Cron, job.ChannelID, history, describeNextRun(job), prompt)
+		}
+		respondEphemeral(discord, interaction, truncateList(list.String()))
+
+	case "remove":
+		id := int(subcommand.Options[0].IntValue())
+		removed, err := scheduler.Remove(interaction.GuildID, id)
+		if err != nil {
+			log.Printf("Error saving scheduled jobs: %v", err)
+			respondEphemeral(discord, interaction, "Sorry, I couldn't save that. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(discord, interaction, fmt.Sprintf("There's no job #%d in this server.", id))
+			return
+		}
+		respondEphemeral(discord, interaction, fmt.Sprintf("Removed job #%d.", id))
+	}
+}
+
+// describeNextRun tells when a job runs next, as a Discord timestamp shown in each reader's timezone
+func describeNextRun(job ScheduledJob) string {
+	next := job.schedule.Next(time.Now().In(botLocation))
+	if next.IsZero() {
+		return "It will never run; check the schedule."
+	}
+	return fmt.Sprintf("Next run <t:%d:F>.", next.Unix())
+}
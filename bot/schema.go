@@ -0,0 +1,243 @@
+package bot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SchemaFor derives a JSON schema from the Go type of v, which should be a struct or a pointer to one.
+// Field names follow `json` tags. Strict mode requires every property to be listed as required, so
+// fields with omitempty are required but may be null. A `description` tag adds a description and an
+// `enum` tag (comma separated) restricts string values.
+func SchemaFor(v any) map[string]any {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return schemaForType(t)
+}
+
+// schemaForType builds the schema for a single Go type
+func schemaForType(t reflect.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, omitempty, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+
+			property := schemaForType(field.Type)
+			if description := field.Tag.Get("description"); description != "" {
+				property["description"] = description
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				values := []any{}
+				for _, value := range strings.Split(enum, ",") {
+					values = append(values, strings.TrimSpace(value))
+				}
+				property["enum"] = values
+			}
+
+			if omitempty {
+				property = nullable(property)
+			}
+			properties[name] = property
+			required = append(required, name)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]any{}
+	}
+}
+
+// nullable lets a property schema also accept null
+func nullable(schema map[string]any) map[string]any {
+	schemaType, ok := schema["type"].(string)
+	if !ok {
+		return schema
+	}
+	schema["type"] = []any{schemaType, "null"}
+	if enum, ok := schema["enum"].([]any); ok {
+		schema["enum"] = append(enum, nil)
+	}
+	return schema
+}
+
+// jsonFieldName returns the JSON name of a struct field and whether it is optional or skipped
+func jsonFieldName(field reflect.StructField) (name string, omitempty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" || option == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// validateAgainstSchema checks a decoded JSON value against the subset of JSON schema produced
+// by SchemaFor: types, required properties, additionalProperties and enums
+func validateAgainstSchema(value any, schema map[string]any, path string) error {
+	if path == "" {
+		path = "$"
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		matched := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(value, allowed) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+
+	schemaType, nullable := schemaTypeOf(schema)
+	if value == nil && nullable {
+		return nil
+	}
+	switch schemaType {
+	case "":
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%s: expected integer", path)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number", path)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		if itemSchema, ok := schema["items"].(map[string]any); ok {
+			for i, item := range items {
+				if err := validateAgainstSchema(item, itemSchema, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range schemaRequired(schema) {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, propertyValue := range object {
+			propertySchema, known := properties[name].(map[string]any)
+			if !known {
+				switch additional := schema["additionalProperties"].(type) {
+				case bool:
+					if !additional {
+						return fmt.Errorf("%s: unexpected property %q", path, name)
+					}
+					continue
+				case map[string]any:
+					propertySchema = additional
+				default:
+					continue
+				}
+			}
+			if err := validateAgainstSchema(propertyValue, propertySchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemaTypeOf returns the type of a schema and whether it also allows null, for a type
+// given either as a string or as a list like ["string", "null"]
+func schemaTypeOf(schema map[string]any) (schemaType string, nullable bool) {
+	switch t := schema["type"].(type) {
+	case string:
+		return t, false
+	case []any:
+		for _, option := range t {
+			if option == "null" {
+				nullable = true
+			} else if s, ok := option.(string); ok && schemaType == "" {
+				schemaType = s
+			}
+		}
+	}
+	return schemaType, nullable
+}
+
+// schemaRequired returns the required property names of an object schema
+func schemaRequired(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		names := make([]string, 0, len(required))
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
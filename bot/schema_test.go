@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type schemaTestNote struct {
+	Title    string   `json:"title" description:"Short title"`
+	Priority string   `json:"priority" enum:"low,high"`
+	Due      string   `json:"due,omitempty"`
+	Level    string   `json:"level,omitempty" enum:"info,warn"`
+	Tags     []string `json:"tags,omitempty"`
+	Count    int      `json:"count"`
+	Internal string   `json:"-"`
+	private  string
+}
+
+func TestSchemaForListsEveryPropertyAsRequired(t *testing.T) {
+	schema := SchemaFor(&schemaTestNote{})
+
+	// Strict mode rejects schemas whose properties are not all required
+	wantRequired := []string{"title", "priority", "due", "level", "tags", "count"}
+	if got := schemaRequired(schema); !reflect.DeepEqual(got, wantRequired) {
+		t.Errorf("expected required %v, got %v", wantRequired, got)
+	}
+	if schema["additionalProperties"] != false {
+		t.Errorf("expected additionalProperties false, got %v", schema["additionalProperties"])
+	}
+
+	properties := schema["properties"].(map[string]any)
+	tests := []struct {
+		property string
+		wantType any
+	}{
+		{"title", "string"},
+		{"count", "integer"},
+		{"due", []any{"string", "null"}},
+		{"tags", []any{"array", "null"}},
+		{"level", []any{"string", "null"}},
+	}
+	for _, tt := range tests {
+		property := properties[tt.property].(map[string]any)
+		if !reflect.DeepEqual(property["type"], tt.wantType) {
+			t.Errorf("%s: expected type %v, got %v", tt.property, tt.wantType, property["type"])
+		}
+	}
+	if got := properties["level"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []any{"info", "warn", nil}) {
+		t.Errorf("expected optional enum to allow null, got %v", got)
+	}
+	if _, ok := properties["Internal"]; ok {
+		t.Error("fields tagged json:\"-\" should be skipped")
+	}
+	if _, ok := properties["private"]; ok {
+		t.Error("unexported fields should be skipped")
+	}
+}
+
+func TestValidateAgainstSchema(t *testing.T) {
+	schema := SchemaFor(&schemaTestNote{})
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "all fields set",
+			input: `{"title":"a","priority":"low","due":"friday","level":"info","tags":["x"],"count":1}`,
+		},
+		{
+			name:  "optional fields null",
+			input: `{"title":"a","priority":"high","due":null,"level":null,"tags":null,"count":0}`,
+		},
+		{
+			name:    "optional field missing",
+			input:   `{"title":"a","priority":"low","level":null,"tags":null,"count":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "required field null",
+			input:   `{"title":null,"priority":"low","due":null,"level":null,"tags":null,"count":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "value outside enum",
+			input:   `{"title":"a","priority":"urgent","due":null,"level":null,"tags":null,"count":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "optional value outside enum",
+			input:   `{"title":"a","priority":"low","due":null,"level":"debug","tags":null,"count":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong item type",
+			input:   `{"title":"a","priority":"low","due":null,"level":null,"tags":[1],"count":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fractional integer",
+			input:   `{"title":"a","priority":"low","due":null,"level":null,"tags":null,"count":1.5}`,
+			wantErr: true,
+		},
+		{
+			name:    "unexpected property",
+			input:   `{"title":"a","priority":"low","due":null,"level":null,"tags":null,"count":1,"extra":true}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(tt.input), &value); err != nil {
+				t.Fatalf("bad test input: %v", err)
+			}
+			err := validateAgainstSchema(value, schema, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDecodeStructuredNullOptionalFields(t *testing.T) {
+	var note schemaTestNote
+	response := "```json\n" + `{"title":"a","priority":"low","due":null,"level":null,"tags":null,"count":2}` + "\n```"
+	if err := decodeStructured(response, SchemaFor(&note), &note); err != nil {
+		t.Fatalf("decodeStructured failed: %v", err)
+	}
+	if note.Title != "a" || note.Due != "" || note.Tags != nil || note.Count != 2 {
+		t.Errorf("unexpected result %+v", note)
+	}
+}
@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxCitationsShown caps the numbered source list rendered beneath an answer
+const maxCitationsShown = 10
+
+// searchCommandName is the command name used for /search and its reasoning override
+const searchCommandName = "search"
+
+// SearchParameters enables xAI live search for a chat completion
+type SearchParameters struct {
+	Mode             string         `json:"mode"` // "auto", "on" or "off"
+	Sources          []SearchSource `json:"sources,omitempty"`
+	FromDate         string         `json:"from_date,omitempty"` // YYYY-MM-DD
+	ToDate           string         `json:"to_date,omitempty"`   // YYYY-MM-DD
+	MaxSearchResults int            `json:"max_search_results,omitempty"`
+	ReturnCitations  bool           `json:"return_citations"`
+}
+
+// SearchSource is a data source live search may consult
+type SearchSource struct {
+	Type string `json:"type"` // "web", "x" or "news"
+}
+
+// isValidSearchSource reports whether source is a live search source the bot can request
+func isValidSearchSource(source string) bool {
+	switch source {
+	case "web", "x", "news":
+		return true
+	default:
+		return false
+	}
+}
+
+// newSearchParameters builds live search parameters for sources, optionally limited to results since from
+func newSearchParameters(mode string, sources []string, from time.Time) *SearchParameters {
+	params := &SearchParameters{
+		Mode:             mode,
+		MaxSearchResults: config.Grok.Search.MaxResults,
+		ReturnCitations:  true,
+	}
+	for _, source := range sources {
+		params.Sources = append(params.Sources, SearchSource{Type: source})
+	}
+	if !from.IsZero() {
+		params.FromDate = from.Format("2006-01-02")
+	}
+	return params
+}
+
+// searchParametersFor returns live search parameters for replies in channelID, or nil when
+// live search is not enabled there. The model decides per message whether to search.
+func searchParametersFor(channelID string) *SearchParameters {
+	if !slices.Contains(config.Grok.Search.Channels, channelID) {
+		return nil
+	}
+	return newSearchParameters("auto", config.Grok.Search.Sources, time.Time{})
+}
+
+// formatCitations renders citation URLs as a numbered source list to place beneath an answer
+func formatCitations(citations []string) string {
+	if len(citations) == 0 {
+		return ""
+	}
+
+	var list strings.Builder
+	list.WriteString("\n\n**Sources:**")
+	for i, citation := range citations {
+		if i == maxCitationsShown {
+			fmt.Fprintf(&list, "\n-# and %d more", len(citations)-maxCitationsShown)
+			break
+		}
+		// Angle brackets stop Discord from unfurling every source
+		fmt.Fprintf(&list, "\n%d. <%s>", i+1, citation)
+	}
+	return list.String()
+}
+
+// searchCommand defines the /search slash command
+func searchCommand() slashCommand {
+	minDays := 1.0
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:        searchCommandName,
+			Description: "Answer a question using live web and X search",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "query",
+					Description: "What to look up",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "sources",
+					Description: "Where to search (defaults to the configured sources)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Web", Value: "web"},
+						{Name: "X", Value: "x"},
+						{Name: "News", Value: "news"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "Only use results from the last N days",
+					MinValue:    &minDays,
+				},
+			},
+		},
+		handler: handleSearchCommand,
+	}
+}
+
+// handleSearchCommand answers /search with live search forced on and the sources listed beneath
+func handleSearchCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	var query string
+	sources := config.Grok.Search.Sources
+	var from time.Time
+	for _, option := range interaction.ApplicationCommandData().Options {
+		switch option.Name {
+		case "query":
+			query = strings.TrimSpace(option.StringValue())
+		case "sources":
+			sources = []string{option.StringValue()}
+		case "days":
+			from = time.Now().AddDate(0, 0, -int(option.IntValue()))
+		}
+	}
+	user := interactionUser(interaction)
+
+	if err := deferResponse(discord, interaction); err != nil {
+		log.Printf("Error deferring /search response: %v", err)
+		return
+	}
+
+	_, err := requestQueue.Submit(interaction.ChannelID, func() {
+		userMessage := CreateTextMessage("user", query, user.Username)
+		messages := buildChatMessages(discord, interaction.ChannelID, chatHistory.Get(interaction.ChannelID), userMessage)
+
+		completion, err := grokClient.CreateChatCompletionDetailed(rootCtx, messages, withPersona(interaction.ChannelID, CompletionOptions{
+			ReasoningEffort: reasoningEffortFor(interaction.GuildID, searchCommandName),
+			Search:          newSearchParameters("on", sources, from),
+		}))
+		if err != nil {
+			log.Printf("Error getting Grok search response: %v", err)
+			editResponse(discord, interaction, "Sorry, I couldn't search for that. Please try again.")
+			return
+		}
+		completion.Content = emojiCatalog.Repair(interaction.GuildID, completion.Content)
+
+		content := fmt.Sprintf("> %s\n\n%s%s", query, completion.Content, formatCitations(completion.Citations))
+		reply, err := editResponseText(discord, interaction, content)
+		if err != nil {
+			log.Printf("Error sending search response: %v", err)
+		}
+
+		assistantMessage := CreateTextMessage("assistant", completion.Content, "")
+		if reply != nil {
+			assistantMessage.MessageID = reply.ID
+		}
+		chatHistory.Append(interaction.ChannelID, userMessage)
+		chatHistory.Append(interaction.ChannelID, assistantMessage)
+	})
+	if err != nil {
+		editResponse(discord, interaction, "I'm swamped in this channel right now, please try again in a moment.")
+	}
+}
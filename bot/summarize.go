@@ -0,0 +1,263 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// summarizeCommandName is the name of the /summarize command, also used for reasoning effort overrides
+const summarizeCommandName = "summarize"
+
+// defaultSummarizeCount is how many messages /summarize reads when neither count nor since is given
+const defaultSummarizeCount = 100
+
+// discordEpoch is the first millisecond of 2015, the zero point of Discord snowflakes
+const discordEpoch = 1420070400000
+
+// summarizeMapPrompt instructs the model summarizing one part of a channel transcript
+const summarizeMapPrompt = `You summarize part of a Discord channel transcript for someone who missed it.
+
+Each line starts with a message reference like [m12], then the author and the message. Write concise bullet points covering decisions, questions that are still open, announcements, action items (with who owns them) and the main topics discussed. Skip small talk. After a bullet, add the reference of the message that best supports it, e.g. "- The release moved to Friday [m12]". Use at most one reference per bullet and only references that appear in the transcript.`
+
+// summarizeReducePrompt instructs the model merging partial summaries into the final digest
+const summarizeReducePrompt = `You merge partial summaries of consecutive parts of a Discord channel into one digest for someone who missed the conversation.
+
+Write a short bulleted digest grouped under bold headings such as **Decisions**, **Open questions**, **Action items** and **Topics**, leaving out empty groups. Merge duplicates, keep the most important points, and keep the message references like [m12] exactly as they appear after the bullets they support.`
+
+// messageReferencePattern matches the [mN] references the model uses to point at messages
+var messageReferencePattern = regexp.MustCompile(`\[m(\d+)\]`)
+
+// summarizeCommand defines the /summarize slash command
+func summarizeCommand() slashCommand {
+	minCount := 1.0
+	maxCount := float64(config.Bot.Summarize.MaxMessages)
+	return slashCommand{
+		definition: &discordgo.ApplicationCommand{
+			Name:        summarizeCommandName,
+			Description: "Summarize recent messages in this channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: fmt.Sprintf("How many recent messages to read (default %d)", defaultSummarizeCount),
+					MinValue:    &minCount,
+					MaxValue:    maxCount,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "since",
+					Description: `How far back to read, e.g. "3h" or "2d", or a message link`,
+				},
+			},
+		},
+		handler: handleSummarizeCommand,
+	}
+}
+
+// handleSummarizeCommand answers /summarize with a digest of the channel's recent messages
+func handleSummarizeCommand(discord *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	count := 0
+	afterID := ""
+	for _, option := range interaction.ApplicationCommandData().Options {
+		switch option.Name {
+		case "count":
+			count = int(option.IntValue())
+		case "since":
+			id, err := parseSince(option.StringValue(), time.Now())
+			if err != nil {
+				respondEphemeral(discord, interaction, fmt.Sprintf("I couldn't understand %q: %v", option.StringValue(), err))
+				return
+			}
+			afterID = id
+		}
+	}
+	if count == 0 {
+		count = config.Bot.Summarize.MaxMessages
+		if afterID == "" {
+			count = min(defaultSummarizeCount, count)
+		}
+	}
+
+	if err := deferResponse(discord, interaction); err != nil {
+		log.Printf("Error deferring /summarize response: %v", err)
+		return
+	}
+
+	_, err := requestQueue.Submit(interaction.ChannelID, func() {
+		messages, err := fetchMessagesSince(rootCtx, discord, interaction.ChannelID, afterID, count)
+		if err != nil && len(messages) == 0 {
+			log.Printf("Error fetching messages to summarize in channel %s: %v", interaction.ChannelID, err)
+			editResponse(discord, interaction, "Sorry, I couldn't read this channel's messages.")
+			return
+		}
+
+		digest, summarized, err := summarizeMessages(rootCtx, discord, interaction.GuildID, interaction.ChannelID, messages)
+		if err != nil {
+			log.Printf("Error summarizing channel %s: %v", interaction.ChannelID, err)
+			editResponse(discord, interaction, "Sorry, I couldn't summarize this channel. Please try again.")
+			return
+		}
+		if summarized == 0 {
+			editResponse(discord, interaction, "There's nothing to summarize.")
+			return
+		}
+
+		content := fmt.Sprintf("**Summary of the last %d messages**\n\n%s", summarized, digest)
+		if _, err := editResponseText(discord, interaction, content); err != nil {
+			log.Printf("Error sending summary: %v", err)
+		}
+	})
+	if err != nil {
+		editResponse(discord, interaction, "I'm swamped in this channel right now, please try again in a moment.")
+	}
+}
+
+// parseSince turns a /summarize since value into the ID of the oldest message to exclude: a
+// duration like "90m", "3h" or "2d" is converted to a snowflake, a message link or ID is used as is
+func parseSince(since string, now time.Time) (string, error) {
+	since = strings.TrimSpace(since)
+	if strings.HasPrefix(since, "https://") {
+		since = since[strings.LastIndex(since, "/")+1:]
+	}
+	if _, err := strconv.ParseUint(since, 10, 64); err == nil && len(since) >= 17 {
+		return since, nil
+	}
+
+	var duration time.Duration
+	if days, ok := strings.CutSuffix(since, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("expected a number of days like 2d")
+		}
+		duration = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(since)
+		if err != nil || parsed <= 0 {
+			return "", fmt.Errorf(`expected a duration like "3h" or "2d", or a message link`)
+		}
+		duration = parsed
+	}
+	return snowflakeAt(now.Add(-duration)), nil
+}
+
+// snowflakeAt returns the smallest Discord snowflake for time t, for paging messages by time
+func snowflakeAt(t time.Time) string {
+	return strconv.FormatInt((t.UnixMilli()-discordEpoch)<<22, 10)
+}
+
+// summarizeMessages summarizes fetched messages (newest first) with map-reduce: the transcript is
+// split into chunks of bot.summarize.chunk_size, each is summarized, and the partial summaries
+// are merged. Message references in the digest become jump links. It returns the digest and
+// the number of messages summarized.
+func summarizeMessages(ctx context.Context, discord *discordgo.Session, guildID, channelID string, messages []*discordgo.Message) (string, int, error) {
+	lines, referenced := transcriptLines(discord, messages)
+	if len(lines) == 0 {
+		return "", 0, nil
+	}
+
+	opts := CompletionOptions{ReasoningEffort: reasoningEffortFor(guildID, summarizeCommandName)}
+
+	// Map: summarize each chunk of the transcript
+	var partials []string
+	for _, chunk := range chunkLines(lines, config.Bot.Summarize.ChunkSize) {
+		summary, err := grokClient.CreateChatCompletionWithOptions(ctx, []ChatMessage{
+			{Role: "system", Content: summarizeMapPrompt},
+			{Role: "user", Content: chunk},
+		}, opts)
+		if err != nil {
+			return "", 0, err
+		}
+		partials = append(partials, summary)
+	}
+
+	// Reduce: merge partial summaries until they fit in one request
+	for len(partials) > 1 {
+		var merged []string
+		for _, chunk := range chunkLines(partials, config.Bot.Summarize.ChunkSize) {
+			summary, err := grokClient.CreateChatCompletionWithOptions(ctx, []ChatMessage{
+				{Role: "system", Content: summarizeReducePrompt},
+				{Role: "user", Content: chunk},
+			}, opts)
+			if err != nil {
+				return "", 0, err
+			}
+			merged = append(merged, summary)
+		}
+		// Stop if merging made no progress, e.g. when single summaries exceed the chunk size
+		if len(merged) >= len(partials) {
+			partials = []string{strings.Join(merged, "\n\n")}
+			break
+		}
+		partials = merged
+	}
+
+	digest := emojiCatalog.Repair(guildID, partials[0])
+	return linkReferences(digest, guildID, channelID, referenced), len(lines), nil
+}
+
+// transcriptLines renders fetched messages (newest first) as "[mN] author: text" lines, oldest
+// first so references read naturally, and returns the messages the references point to
+func transcriptLines(discord *discordgo.Session, messages []*discordgo.Message) ([]string, []*discordgo.Message) {
+	var lines []string
+	var referenced []*discordgo.Message
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := messages[i]
+		if message.Author == nil {
+			continue
+		}
+		text := strings.TrimSpace(message.Content)
+		if len(message.Attachments) > 0 {
+			text = strings.TrimSpace(text + " (attachment)")
+		}
+		if text == "" {
+			continue
+		}
+		author := message.Author.Username
+		if isOwnMessage(discord, message) {
+			author = "Grok"
+		}
+		referenced = append(referenced, message)
+		lines = append(lines, fmt.Sprintf("[m%d] %s: %s", len(referenced), author, text))
+	}
+	return lines, referenced
+}
+
+// linkReferences replaces [mN] references in model output with jump links to the referenced
+// messages, dropping references that point nowhere
+func linkReferences(content, guildID, channelID string, referenced []*discordgo.Message) string {
+	return messageReferencePattern.ReplaceAllStringFunc(content, func(reference string) string {
+		n, err := strconv.Atoi(messageReferencePattern.FindStringSubmatch(reference)[1])
+		if err != nil || n < 1 || n > len(referenced) {
+			return ""
+		}
+		// Angle brackets stop Discord from embedding every linked message
+		return fmt.Sprintf("([jump](<%s>))", messageLink(guildID, channelID, referenced[n-1].ID))
+	})
+}
+
+// chunkLines joins lines into chunks of at most size bytes; a single longer line becomes its own chunk
+func chunkLines(lines []string, size int) []string {
+	var chunks []string
+	var chunk strings.Builder
+	for _, line := range lines {
+		if chunk.Len() > 0 && chunk.Len()+1+len(line) > size {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+		}
+		if chunk.Len() > 0 {
+			chunk.WriteString("\n")
+		}
+		chunk.WriteString(line)
+	}
+	if chunk.Len() > 0 {
+		chunks = append(chunks, chunk.String())
+	}
+	return chunks
+}
@@ -0,0 +1,244 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TriggerKind names the rule that made a message address the bot
+type TriggerKind string
+
+// Trigger kinds, in the order rules are checked. TriggerChimeIn is only produced by live handling.
+const (
+	TriggerNone        TriggerKind = ""
+	TriggerPrefix      TriggerKind = "prefix"
+	TriggerMention     TriggerKind = "mention"
+	TriggerRoleMention TriggerKind = "role_mention"
+	TriggerReply       TriggerKind = "reply"
+	TriggerKeyword     TriggerKind = "keyword"
+	TriggerChimeIn     TriggerKind = "chime_in"
+)
+
+// Trigger is the result of checking a message against the trigger rules
+type Trigger struct {
+	Kind    TriggerKind
+	Content string // Message text with bot mentions, command prefixes and matched keywords removed
+}
+
+// Addressed reports whether the message should be answered
+func (t Trigger) Addressed() bool {
+	return t.Kind != TriggerNone
+}
+
+// TriggerEngine decides whether a message addresses the bot. Live message handling,
+// edits and history backfill all use the same rules.
+type TriggerEngine struct {
+	rules TriggerConfig
+}
+
+// NewTriggerEngine constructs a TriggerEngine from the configured rules
+func NewTriggerEngine(rules TriggerConfig) *TriggerEngine {
+	return &TriggerEngine{rules: rules}
+}
+
+// Match checks message against the deterministic rules: prefix commands, bot mentions,
+// mentions of the bot's roles, replies to the bot and name keywords
+func (e *TriggerEngine) Match(discord *discordgo.Session, message *discordgo.Message) Trigger {
+	botID := discord.State.User.ID
+	if message.Author == nil || message.Author.ID == botID {
+		return Trigger{}
+	}
+
+	content := strings.TrimSpace(message.Content)
+	kind := TriggerNone
+
+	if prefix, ok := e.matchPrefix(content); ok {
+		kind = TriggerPrefix
+		content = trimSeparator(content[len(prefix):])
+	}
+
+	if e.rules.Mentions && (doesMessageMention(message.Mentions, botID) || containsUserMention(content, botID)) {
+		if kind == TriggerNone {
+			kind = TriggerMention
+		}
+		content = stripUserMention(content, botID)
+	}
+
+	if e.rules.RoleMentions {
+		botRoles := e.botRoleIDs(discord, message)
+		for _, roleID := range message.MentionRoles {
+			if !slices.Contains(botRoles, roleID) {
+				continue
+			}
+			if kind == TriggerNone {
+				kind = TriggerRoleMention
+			}
+			content = strings.TrimSpace(strings.ReplaceAll(content, fmt.Sprintf("<@&%s>", roleID), ""))
+		}
+	}
+
+	// Replies to persona webhook messages count as replies to the bot
+	if kind == TriggerNone && e.rules.Replies && message.ReferencedMessage != nil {
+		referenced := *message.ReferencedMessage
+		if referenced.ChannelID == "" {
+			referenced.ChannelID = message.ChannelID
+		}
+		if isOwnMessage(discord, &referenced) {
+			kind = TriggerReply
+		}
+	}
+
+	if kind == TriggerNone {
+		if keyword, ok := e.matchKeyword(content); ok {
+			kind = TriggerKeyword
+			content = stripWord(content, keyword)
+		}
+	}
+
+	return Trigger{Kind: kind, Content: content}
+}
+
+// ChimeIn rolls whether the bot should answer a message that did not address it
+func (e *TriggerEngine) ChimeIn() bool {
+	return e.rules.ChimeInProbability > 0 && rand.Float64() < e.rules.ChimeInProbability
+}
+
+// matchPrefix returns the configured command prefix content starts with, if any
+func (e *TriggerEngine) matchPrefix(content string) (string, bool) {
+	for _, prefix := range e.rules.Prefixes {
+		if prefix == "" || len(content) < len(prefix) {
+			continue
+		}
+		if strings.EqualFold(content[:len(prefix)], prefix) && wordBoundaryAfter(content, len(prefix), prefix) {
+			return content[:len(prefix)], true
+		}
+	}
+	return "", false
+}
+
+// matchKeyword returns the configured name keyword content contains as a whole word, if any
+func (e *TriggerEngine) matchKeyword(content string) (string, bool) {
+	lower := strings.ToLower(content)
+	for _, keyword := range e.rules.Keywords {
+		if keyword != "" && containsWord(lower, strings.ToLower(keyword)) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// botRoleIDs returns the IDs of the bot's roles in the guild a message was posted in
+func (e *TriggerEngine) botRoleIDs(discord *discordgo.Session, message *discordgo.Message) []string {
+	roles := append([]string{}, e.rules.Roles...)
+
+	guildID := message.GuildID
+	if guildID == "" {
+		// Messages fetched over REST carry no guild ID
+		if channel, err := discord.State.Channel(message.ChannelID); err == nil {
+			guildID = channel.GuildID
+		}
+	}
+	if guildID == "" {
+		return roles
+	}
+
+	if member, err := discord.State.Member(guildID, discord.State.User.ID); err == nil {
+		roles = append(roles, member.Roles...)
+	}
+	return roles
+}
+
+// containsUserMention reports whether content contains a raw mention of userID
+func containsUserMention(content, userID string) bool {
+	return strings.Contains(content, fmt.Sprintf("<@%s>", userID)) || strings.Contains(content, fmt.Sprintf("<@!%s>", userID))
+}
+
+// stripUserMention removes both mention forms of userID from content
+func stripUserMention(content, userID string) string {
+	content = strings.ReplaceAll(content, fmt.Sprintf("<@%s>", userID), "")
+	content = strings.ReplaceAll(content, fmt.Sprintf("<@!%s>", userID), "")
+	return strings.TrimSpace(content)
+}
+
+// stripWord removes whole-word, case-insensitive occurrences of word from text, along with
+// the separator and space that followed each, so "hey grok, hi" becomes "hey hi"
+func stripWord(text, word string) string {
+	var out strings.Builder
+	for offset := 0; offset < len(text); {
+		end := offset + len(word)
+		if end <= len(text) && strings.EqualFold(text[offset:end], word) &&
+			wordBoundaryBefore(text, offset, word) && wordBoundaryAfter(text, end, word) {
+			offset = end
+			if offset < len(text) && strings.ContainsRune(separatorChars, rune(text[offset])) {
+				offset++
+			}
+			if offset < len(text) && text[offset] == ' ' {
+				offset++
+			}
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		out.WriteString(text[offset : offset+size])
+		offset += size
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// separatorChars may follow a prefix or name that addresses the bot, as in "grok, hi"
+const separatorChars = ",:;"
+
+// trimSeparator removes surrounding space and a leading separator from text following a prefix
+func trimSeparator(text string) string {
+	text = strings.TrimSpace(text)
+	if text != "" && strings.ContainsRune(separatorChars, rune(text[0])) {
+		text = strings.TrimSpace(text[1:])
+	}
+	return text
+}
+
+// containsWord reports whether word occurs in text without being part of a longer word
+func containsWord(text, word string) bool {
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], word)
+		if index < 0 {
+			return false
+		}
+		start := offset + index
+		end := start + len(word)
+		if wordBoundaryBefore(text, start, word) && wordBoundaryAfter(text, end, word) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// wordBoundaryBefore reports whether the text before start does not continue word
+func wordBoundaryBefore(text string, start int, word string) bool {
+	first, _ := utf8.DecodeRuneInString(word)
+	if start == 0 || !isWordRune(first) {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	return !isWordRune(before)
+}
+
+// wordBoundaryAfter reports whether the text from end does not continue word
+func wordBoundaryAfter(text string, end int, word string) bool {
+	last, _ := utf8.DecodeLastRuneInString(word)
+	if end >= len(text) || !isWordRune(last) {
+		return true
+	}
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(after)
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newTestSession returns a session whose state knows only the bot's own user
+func newTestSession(botID string) *discordgo.Session {
+	state := discordgo.NewState()
+	state.User = &discordgo.User{ID: botID}
+	return &discordgo.Session{State: state}
+}
+
+func TestTriggerEngineMatch(t *testing.T) {
+	engine := NewTriggerEngine(TriggerConfig{
+		Mentions: true,
+		Prefixes: []string{"!grok"},
+		Keywords: []string{"grok"},
+	})
+	discord := newTestSession("bot")
+
+	tests := []struct {
+		name        string
+		content     string
+		mentions    []*discordgo.User
+		wantKind    TriggerKind
+		wantContent string
+	}{
+		{"prefix", "!grok what time is it", nil, TriggerPrefix, "what time is it"},
+		{"prefix with comma", "!grok, what time is it", nil, TriggerPrefix, "what time is it"},
+		{"prefix with colon", "!grok: what time is it", nil, TriggerPrefix, "what time is it"},
+		{"prefix without space", "!grok,what time is it", nil, TriggerPrefix, "what time is it"},
+		{"prefix in other case", "!GROK hi", nil, TriggerPrefix, "hi"},
+		{"prefix alone", "!grok", nil, TriggerPrefix, ""},
+		{"prefix inside longer word", "!grokking is fun", nil, TriggerNone, "!grokking is fun"},
+		{"mention", "<@bot> hi there", []*discordgo.User{{ID: "bot"}}, TriggerMention, "hi there"},
+		{"nickname mention", "<@!bot> hi there", nil, TriggerMention, "hi there"},
+		{"prefix and mention", "!grok <@bot> hi", []*discordgo.User{{ID: "bot"}}, TriggerPrefix, "hi"},
+		{"keyword", "grok what time is it", nil, TriggerKeyword, "what time is it"},
+		{"keyword with comma", "hey grok, what time is it", nil, TriggerKeyword, "hey what time is it"},
+		{"keyword inside longer word", "grokking is fun", nil, TriggerNone, "grokking is fun"},
+		{"not addressed", "what time is it", nil, TriggerNone, "what time is it"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := &discordgo.Message{
+				Content:  tt.content,
+				Author:   &discordgo.User{ID: "user"},
+				Mentions: tt.mentions,
+			}
+			trigger := engine.Match(discord, message)
+			if trigger.Kind != tt.wantKind || trigger.Content != tt.wantContent {
+				t.Errorf("expected (%q, %q), got (%q, %q)", tt.wantKind, tt.wantContent, trigger.Kind, trigger.Content)
+			}
+		})
+	}
+}
+
+func TestTriggerEngineIgnoresOwnMessages(t *testing.T) {
+	engine := NewTriggerEngine(TriggerConfig{Prefixes: []string{"!grok"}})
+	message := &discordgo.Message{Content: "!grok hi", Author: &discordgo.User{ID: "bot"}}
+	if trigger := engine.Match(newTestSession("bot"), message); trigger.Addressed() {
+		t.Errorf("expected the bot's own message to be ignored, got %q", trigger.Kind)
+	}
+}
+
+func TestStripWord(t *testing.T) {
+	tests := []struct {
+		text string
+		word string
+		want string
+	}{
+		{"grok hello", "grok", "hello"},
+		{"Grok hello", "grok", "hello"},
+		{"hello grok", "grok", "hello"},
+		{"hey grok, hello", "grok", "hey hello"},
+		{"grok: hello", "grok", "hello"},
+		{"grok; grok hello", "grok", "hello"},
+		{"grokking grok", "grok", "grokking"},
+		{"no match here", "grok", "no match here"},
+		{"héllo grok", "grok", "héllo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := stripWord(tt.text, tt.word); got != tt.want {
+				t.Errorf("stripWord(%q, %q) = %q, want %q", tt.text, tt.word, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// typingRefreshInterval is how often the typing indicator is re-sent.
+// Discord clears the indicator roughly 10 seconds after the last trigger.
+const typingRefreshInterval = 8 * time.Second
+
+// thinkingStatusMessage is posted when a response takes longer than bot.thinking_message_after
+const thinkingStatusMessage = "Still thinking..."
+
+// keepTyping shows the typing indicator in channelID until ctx is done or the
+// returned stop function is called. If the response is slow, a status message is
+// posted after bot.thinking_message_after and removed again when typing stops.
+func keepTyping(ctx context.Context, discord *discordgo.Session, channelID string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(typingRefreshInterval)
+		defer ticker.Stop()
+
+		var slow <-chan time.Time
+		if config.Bot.ThinkingMessageAfter > 0 {
+			timer := time.NewTimer(config.Bot.ThinkingMessageAfter)
+			defer timer.Stop()
+			slow = timer.C
+		}
+
+		var status *discordgo.Message
+		discord.ChannelTyping(channelID)
+		for {
+			select {
+			case <-ctx.Done():
+				if status != nil {
+					if err := discord.ChannelMessageDelete(channelID, status.ID); err != nil {
+						log.Printf("Error removing status message in channel %s: %v", channelID, err)
+					}
+				}
+				return
+			case <-ticker.C:
+				discord.ChannelTyping(channelID)
+			case <-slow:
+				msg, err := discord.ChannelMessageSend(channelID, thinkingStatusMessage)
+				if err != nil {
+					log.Printf("Error sending status message in channel %s: %v", channelID, err)
+					continue
+				}
+				status = msg
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// webhookName is the name of the webhooks the bot creates to post as personas
+const webhookName = "Grok Personas"
+
+// webhookRetryAfter is how long a channel where webhooks failed is skipped before trying again
+const webhookRetryAfter = time.Hour
+
+// errWebhooksUnavailable means persona replies must fall back to normal bot messages
+var errWebhooksUnavailable = errors.New("webhooks unavailable in channel")
+
+// WebhookManager creates, caches and reuses one bot-owned webhook per channel. mu guards the
+// maps and is never held across REST calls; lookups in a channel are serialized by its entry
+// in channels so concurrent replies don't each create a webhook.
+type WebhookManager struct {
+	mu          sync.Mutex
+	channels    map[string]*sync.Mutex
+	webhooks    map[string]*discordgo.Webhook
+	unavailable map[string]time.Time
+	missing     map[string]time.Time // Channels Owns found no bot webhook in, and when
+}
+
+// NewWebhookManager constructs an empty WebhookManager
+func NewWebhookManager() *WebhookManager {
+	return &WebhookManager{
+		channels:    make(map[string]*sync.Mutex),
+		webhooks:    make(map[string]*discordgo.Webhook),
+		unavailable: make(map[string]time.Time),
+		missing:     make(map[string]time.Time),
+	}
+}
+
+// Get returns the bot's webhook for channelID, reusing an existing one or creating it.
+// It returns errWebhooksUnavailable when the bot lacks Manage Webhooks there.
+func (m *WebhookManager) Get(discord *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	lock := m.channelLock(channelID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mu.Lock()
+	webhook, cached := m.webhooks[channelID]
+	since, failed := m.unavailable[channelID]
+	m.mu.Unlock()
+	if cached {
+		return webhook, nil
+	}
+	if failed && time.Since(since) < webhookRetryAfter {
+		return nil, errWebhooksUnavailable
+	}
+
+	permissions, err := discord.State.UserChannelPermissions(discord.State.User.ID, channelID)
+	if err == nil && permissions&discordgo.PermissionManageWebhooks == 0 {
+		m.markUnavailable(channelID)
+		return nil, errWebhooksUnavailable
+	}
+
+	webhook, err = m.findOrCreate(discord, channelID)
+	if err != nil {
+		m.markUnavailable(channelID)
+		return nil, fmt.Errorf("%w: %v", errWebhooksUnavailable, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.unavailable, channelID)
+	// Whatever Owns cached about the channel is out of date once the bot has a webhook there
+	delete(m.missing, channelID)
+	m.webhooks[channelID] = webhook
+	return webhook, nil
+}
+
+// channelLock returns the mutex serializing webhook lookups in channelID
+func (m *WebhookManager) channelLock(channelID string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.channels[channelID]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.channels[channelID] = lock
+	}
+	return lock
+}
+
+// markUnavailable records that webhooks could not be used in channelID
+func (m *WebhookManager) markUnavailable(channelID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unavailable[channelID] = time.Now()
+}
+
+// Forget drops the cached webhook for channelID, e.g. after it was deleted
+func (m *WebhookManager) Forget(channelID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.webhooks, channelID)
+}
+
+// Owns reports whether webhookID is the bot's persona webhook in channelID, looking it up
+// without creating one when it is not cached yet. Channels without a bot webhook, or where the
+// bot can't list webhooks, are remembered for webhookRetryAfter so busy channels of other
+// webhook bots don't cost a request per message.
+func (m *WebhookManager) Owns(discord *discordgo.Session, channelID, webhookID string) bool {
+	lock := m.channelLock(channelID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mu.Lock()
+	webhook, cached := m.webhooks[channelID]
+	since, missing := m.missing[channelID]
+	m.mu.Unlock()
+	if cached {
+		return webhook.ID == webhookID
+	}
+	if missing && time.Since(since) < webhookRetryAfter {
+		return false
+	}
+
+	permissions, err := discord.State.UserChannelPermissions(discord.State.User.ID, channelID)
+	if err == nil && permissions&discordgo.PermissionManageWebhooks == 0 {
+		m.markMissing(channelID)
+		return false
+	}
+	webhook, err = m.find(discord, channelID)
+	if err != nil || webhook == nil {
+		m.markMissing(channelID)
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.webhooks[channelID] = webhook
+	return webhook.ID == webhookID
+}
+
+// markMissing records that Owns found no bot webhook in channelID
+func (m *WebhookManager) markMissing(channelID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.missing[channelID] = time.Now()
+}
+
+// find returns the webhook the bot created earlier in channelID, or nil if there is none
+func (m *WebhookManager) find(discord *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	webhooks, err := discord.ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		if webhook.Name == webhookName && webhook.Token != "" && webhook.User != nil && webhook.User.ID == discord.State.User.ID {
+			return webhook, nil
+		}
+	}
+	return nil, nil
+}
+
+// findOrCreate returns the webhook the bot created earlier in channelID and creates one if there is none
+func (m *WebhookManager) findOrCreate(discord *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	webhook, err := m.find(discord, channelID)
+	if err != nil || webhook != nil {
+		return webhook, err
+	}
+	return discord.WebhookCreate(channelID, webhookName, "")
+}
+
+// isOwnMessage reports whether a message was posted by the bot, directly or through its persona webhook
+func isOwnMessage(discord *discordgo.Session, message *discordgo.Message) bool {
+	if message.Author != nil && message.Author.ID == discord.State.User.ID {
+		return true
+	}
+	return message.WebhookID != "" && config.Bot.WebhookReplies && webhooks.Owns(discord, message.ChannelID, message.WebhookID)
+}
+
+// personaWebhookChannel reports whether replies in channelID should be posted through a webhook
+// as the active persona. Threads are excluded because webhook messages in threads cannot be edited.
+func personaWebhookChannel(discord *discordgo.Session, channelID string) (*PersonaConfig, bool) {
+	if !config.Bot.WebhookReplies {
+		return nil, false
+	}
+	persona := activePersona(channelID)
+	if persona == nil {
+		return nil, false
+	}
+	if channel, err := discord.State.Channel(channelID); err == nil && channel.IsThread() {
+		return nil, false
+	}
+	return persona, true
+}
+
+// postMessage sends a message to a channel, as the active persona through a webhook when
+// bot.webhook_replies is on and possible, otherwise as the bot
+func postMessage(discord *discordgo.Session, channelID string, message *discordgo.MessageSend) (*discordgo.Message, error) {
+	persona, ok := personaWebhookChannel(discord, channelID)
+	if !ok {
+		return discord.ChannelMessageSendComplex(channelID, message)
+	}
+
+	username := persona.Nickname
+	if username == "" {
+		username = persona.Name
+	}
+	params := &discordgo.WebhookParams{
+		Content:         message.Content,
+		Username:        username,
+		AvatarURL:       persona.AvatarURL,
+		Files:           message.Files,
+		AllowedMentions: message.AllowedMentions,
+	}
+
+	// Retry once with a fresh webhook in case the cached one was deleted
+	for attempt := 0; attempt < 2; attempt++ {
+		webhook, err := webhooks.Get(discord, channelID)
+		if err != nil {
+			if err != errWebhooksUnavailable {
+				log.Printf("Posting as the bot in channel %s: %v", channelID, err)
+			}
+			return discord.ChannelMessageSendComplex(channelID, message)
+		}
+
+		sent, err := discord.WebhookExecute(webhook.ID, webhook.Token, true, params)
+		if err == nil {
+			return sent, nil
+		}
+		var restErr *discordgo.RESTError
+		if !errors.As(err, &restErr) || restErr.Message == nil || restErr.Message.Code != discordgo.ErrCodeUnknownWebhook {
+			return nil, err
+		}
+		webhooks.Forget(channelID)
+	}
+	return discord.ChannelMessageSendComplex(channelID, message)
+}
+
+// editPostedMessage edits a message sent by postMessage, through the webhook if it was posted by one
+func editPostedMessage(discord *discordgo.Session, edit *discordgo.MessageEdit) error {
+	if config.Bot.WebhookReplies {
+		if original, err := discord.ChannelMessage(edit.Channel, edit.ID); err == nil && original.WebhookID != "" {
+			webhook, err := webhooks.Get(discord, edit.Channel)
+			if err != nil {
+				return err
+			}
+			if webhook.ID != original.WebhookID {
+				return fmt.Errorf("message %s was posted by another webhook", edit.ID)
+			}
+			_, err = discord.WebhookMessageEdit(webhook.ID, webhook.Token, edit.ID, &discordgo.WebhookEdit{
+				Content:     edit.Content,
+				Files:       edit.Files,
+				Attachments: edit.Attachments,
+			})
+			return err
+		}
+	}
+
+	_, err := discord.ChannelMessageEditComplex(edit)
+	return err
+}
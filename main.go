@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -15,6 +16,9 @@ import (
 	"grok-bot/bot"
 )
 
+// startTime records when the process started, for reporting uptime
+var startTime = time.Now()
+
 func main() {
 	// Parse command line flags
 	var configPath string
@@ -34,6 +38,12 @@ func main() {
 		fmt.Println("Using default configuration with environment variables")
 	}
 
+	// Subcommands run instead of the bot
+	if flag.Arg(0) == "ingest" {
+		runIngest(config, flag.Args()[1:])
+		return
+	}
+
 	// Create a context that can be cancelled
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -121,6 +131,32 @@ func main() {
 	}
 }
 
+// runIngest implements `grok-bot ingest [-name kb] <dir>`, indexing a directory of Markdown and
+// text files as a knowledge base
+func runIngest(config *bot.Config, args []string) {
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	name := flags.String("name", "default", "Name of the knowledge base, referenced by bot.knowledge.guilds")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: grok-bot [-config path] ingest [-name kb] <dir>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Ingesting %s into knowledge base %q...", flags.Arg(0), *name)
+	files, chunks, err := bot.Ingest(ctx, config, *name, flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to ingest %s: %v", flags.Arg(0), err)
+	}
+	log.Printf("Ingested %d files as %d chunks into knowledge base %q", files, chunks, *name)
+}
+
 // HTTP handlers
 func handleRoot(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
@@ -157,10 +193,12 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 
 func handleStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{
-		"bot": "running",
-		"server": "running",
-		"timestamp": "%s",
-		"uptime": "%s"
-	}`, time.Now().Format(time.RFC3339), time.Since(time.Now()).String())
+	json.NewEncoder(w).Encode(map[string]any{
+		"bot":       "running",
+		"server":    "running",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"uptime":    time.Since(startTime).Round(time.Second).String(),
+		"backfill":  bot.GetBackfillStatus(),
+		"usage":     bot.GetUsage(),
+	})
 }